@@ -8,7 +8,12 @@ import (
 	"time"
 	"solver"
 	"os"
+	"bench"
+	"flag"
+	"encoding/json"
 	"path/filepath"
+	"math/big"
+	"strings"
 )
 
 // Global variables
@@ -25,7 +30,6 @@ func main() {
 	// Local variables
 	var Status int
 	Point := make([]float64, lp.NumCols)
-	var ReadDirectory bool
 
 	// Timing variables
 	var TotalRunTime time.Duration
@@ -52,63 +56,63 @@ func main() {
 	//MaxSwarmPts = 2*NumCPUs  // So we only run through 2 jobs per CPU per round
 	MaxSwarmPts = NumCPUs
 	solver.PrintLevel = 1	// PrintLevel = 0 turns off the printing so you can run through a set of files
-	ReadDirectory = false	// true: run through all models in a directory. false: just run one file
+	lp.AccurateSums = false	// true: compensated sums in the row evaluations, for numerically difficult models
 	
-	if ReadDirectory {
-		// Run all of the files in a particular directory
-		// Get a list of the files in a directory
-		var MPSfiles []string
-		MPSfiles, _ = filepath.Glob("c:/chinneck/projects/CCLP/NetlibMPS/TestSetOriginal/*") // change the directory you want to run through
-		f, _ := os.Create("c:/chinneck/projects/CCLP/CCLPv6/CCSequentialImpact.txt") // create a summary file to write to. Give it a unique name related to the experiment
-		defer f.Close()
-		fmt.Fprintln(f,"CCLPv6 50 boxes, killed if 5 in a row no incumbent improvement, no CV projection, CC sequential impact")	// Fill in title of the run
-		// List the column titles for the data that gets filled in
-		fmt.Fprintln(f,"Model NINF SFD BoxNum ExitPtType ReadTime CalcTime LinProjSucc LinProjTries LinProjImp QuadProjSucc QuadProjTries QuadProjImp IncUpdates",
-			"NumIncUpdates0 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20 21 22",
-			"FracIncUpdates0 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20 21 22")
-		
-		for i:=0; i< len(MPSfiles); i++ {
-			StartTime := time.Now()
-			fmt.Println("FILE:",MPSfiles[i])
-			Status := lp.ReadMPSFile(MPSfiles[i], plinfy, featol)
-			if Status > 0 {
-				fmt.Println("  Errors reading MPS file. Aborting this model.")
-				continue
-			}
-			ModelReadinTime = time.Since(StartTime)
-			if solver.PrintLevel > 0 {lp.PrintStatistics()}
-			CalculationStartTime := time.Now()
-			// Scale the model
-			//lp.ScaleColumns()
-			//lp.ScaleRows()
-			// Call the solver
-			Point, Status = solver.Solve(Alpha, Beta, MaxItns, MaxSwarmPts, plinfy, featol)
-			Point[0]=Point[0]+0.0 //Needed so value is used. Can later print out the point if needed.			
-			// Determine total time and Calculation time
-			CalculationTime = time.Since(CalculationStartTime)
-			TotalRunTime = time.Since(StartTime)
-			fmt.Fprintln(f, MPSfiles[i],solver.IncumbentNINF,solver.IncumbentSFD,solver.FinalBox,solver.FinalPointType,
-				ModelReadinTime.Seconds(),CalculationTime.Seconds(),
-				solver.LinProjSucceeds,solver.LinProjSucceeds+solver.LinProjFails,solver.LinProjFrac/float64(solver.LinProjSucceeds),
-				solver.QuadProjSucceeds,solver.QuadProjSucceeds+solver.QuadProjFails,solver.QuadProjFrac/float64(solver.QuadProjSucceeds),
-				solver.TotUpdates-1, solver.NumUpdate, solver.FracUpdate )
-			//solver.WG.Wait()
-			fmt.Println("-------------------Finished number", i, "of", len(MPSfiles)-1,"--------------------------------")
-			// wipe the model and restart
-			// the following wait is not needed if we use the waitgroup method above. 
-			time.Sleep(5*time.Second)  // wait a while for any running go routines to finish gracefully
-			lp.EmptyMPS() // empty the MPS file so a new one can be read in
-		}
-		fmt.Println("DONE!")
-		os.Exit(0)
+	// Commands other than solving a single file
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "batch":
+			os.Exit(BatchCommand(os.Args[2:]))
+		case "compare":
+			os.Exit(CompareCommand(os.Args[2:]))
+		case "profile":
+			os.Exit(ProfileCommand(os.Args[2:]))
+		case "verify":
+			os.Exit(VerifyCommand(os.Args[2:]))
+		case bench.ChildCommand:
+			os.Exit(SolveModelCommand(os.Args[2:]))
+		}
 	}
 
 	// Just run for one file -------------------------------------------------
+	Flags := flag.NewFlagSet("CCLPv7", flag.ExitOnError)
+	SolFile := Flags.String("sol", "", "write the final point to this solution file")
+	SolFormat := Flags.String("solformat", "", "text or json (default: from the solution file extension)")
+	StartFiles := Flags.String("start", "", "warm start CC from the points in these solution files (comma-separated)")
+	Flags.BoolVar(&lp.AccurateSums, "accurate", lp.AccurateSums, "use compensated sums in the row evaluations")
+	Flags.Var(&solver.TolMode, "tolmode", "feasibility tolerance mode: absolute, relative or rownorm")
+	Flags.BoolVar(&solver.MIPMode, "mip", solver.MIPMode, "enforce integrality on the integer columns, alternating CC repair with rounding")
+	Flags.Var(&solver.RoundMode, "round", "rounding in the MIP mode: simple, random or ordered")
+	Flags.BoolVar(&solver.LocalSearch, "localsearch", solver.LocalSearch, "after each round, run a local search on the integer columns from the NINF incumbent")
+	Flags.IntVar(&solver.LSMaxMoves, "lsmoves", solver.LSMaxMoves, "maximum number of moves per local search")
+	SimplexMode := Flags.String("simplex", "", "polish: finish the CC incumbent with the bounded simplex; cold: solve with the simplex alone")
+	Phase2 := Flags.Bool("phase2", false, "with -simplex, also minimize the objective row")
+	Optimize := Flags.Bool("optimize", false, "after a feasible point is found, lower the objective with an objective cut and further CC runs")
+	Flags.IntVar(&solver.OptMaxSteps, "optsteps", solver.OptMaxSteps, "with -optimize, the maximum number of objective targets")
+	Flags.IntVar(&solver.OptRounds, "optrounds", solver.OptRounds, "with -optimize, the CC rounds per objective target")
+	Flags.DurationVar(&solver.OptTimeLimit, "opttime", solver.OptTimeLimit, "with -optimize, the time limit on the optimization (0: none)")
+	IIS := Flags.Bool("iis", false, "if no feasible point is found, find an irreducible infeasible subset of rows and bounds")
+	Flags.Usage = func() {
+		fmt.Println("Usage: CCLPv7 [options] file.mps")
+		fmt.Println("       CCLPv7 batch|compare|profile|verify [options] ...")
+		Flags.PrintDefaults()
+	}
+	Flags.Parse(os.Args[1:])
+	inputMPS = Flags.Arg(0)
+	if *SolFormat == "" {
+		*SolFormat = "text"
+		if filepath.Ext(*SolFile) == ".json" {
+			*SolFormat = "json"
+		}
+	}
 
 	// Read in the MPS file
-	inputMPS = "c:/chinneck/projects/CCLP/NetlibMPS/AllModelsOriginal/25fv47.mps"  // Location of the single file to solve
+	if inputMPS == "" {
+		inputMPS = "c:/chinneck/projects/CCLP/NetlibMPS/AllModelsOriginal/25fv47.mps"  // Location of the single file to solve
+	}
 	fmt.Println("Model:",inputMPS)
 	StartTime := time.Now()
+	lp.KeepExact = *IIS	// the exact data certifies the IIS
 	Status = lp.ReadMPSFile(inputMPS, plinfy, featol)
 	if Status > 0 {
 		fmt.Println("Errors reading MPS file: exiting main program.")
@@ -123,8 +127,45 @@ func main() {
 	if solver.PrintLevel > 0 {lp.PrintStatistics()}
 		
 	// Call the solver
-	Point, Status = solver.Solve(Alpha, Beta, MaxItns, MaxSwarmPts, plinfy, featol)
-	Point[0]=Point[0]+0.0 //Needed so value is used. Can later print out the point if needed.
+	if *SimplexMode != "" && *SimplexMode != "polish" && *SimplexMode != "cold" {
+		fmt.Println("Error: unknown simplex mode", *SimplexMode)
+		os.Exit(1)
+	}
+	var Warm solver.WARMSTART
+	if *StartFiles != "" {
+		for _, FileName := range strings.Split(*StartFiles, ",") {
+			StartPt, Format, ReadStatus := solver.ReadSolution(FileName)
+			if ReadStatus > 0 {
+				os.Exit(1)
+			}
+			fmt.Println("Warm start point read from", FileName, "(", Format, "format )")
+			Warm.Points = append(Warm.Points, StartPt)
+		}
+	}
+	if *SimplexMode == "cold" {
+		if Warm.Points != nil {fmt.Println("Warning: the warm start points are not used by the simplex from a cold start.")}
+		solver.SetTolerances(plinfy, featol)
+		Point, Status = RunSimplex(nil, *Phase2)
+	} else {
+		Point, Status = solver.SolveWarm(Warm, Alpha, Beta, MaxItns, MaxSwarmPts, plinfy, featol)
+		if *SimplexMode == "polish" && (Status != 0 || *Phase2) {
+			if SimplexPt, SimplexStatus := RunSimplex(Point, *Phase2); SimplexStatus == 0 {
+				Point, Status = SimplexPt, 0
+			}
+		}
+	}
+	if *Optimize && Status == 0 {
+		OptStart := time.Now()
+		Result, OptStatus := solver.Optimize(Point, Alpha, Beta, MaxItns, MaxSwarmPts, plinfy, featol)
+		if OptStatus == 0 {
+			fmt.Println("\nObjective optimization:", Result.NumImproved, "of", Result.NumSteps, "targets reached in", time.Since(OptStart).Seconds(), "s")
+			fmt.Println("  Starting objective:", Result.StartObj, "  best feasible objective:", Result.Objective)
+			if Result.FailedBelow > -plinfy {
+				fmt.Println("  Lowest target not reached:", Result.FailedBelow)
+			}
+			Point = Result.Point
+		}
+	}
 	if solver.PrintLevel > 0{fmt.Println("\nBack in Main routine...")}
 	
 	// Determine total time and Calculation time
@@ -140,12 +181,23 @@ func main() {
 		// Summarize results
 		if Status == 0 {
 			fmt.Println("Feasible point found.")
+		} else if *SimplexMode == "cold" {
+			fmt.Println("No feasible point found by the simplex.")
 		} else {
 			fmt.Println("No feasible point found. Incumbent SFD:",solver.IncumbentSFD,"NINF:",solver.IncumbentNINF)
 			fmt.Println("Smallest NINF:",solver.SmallestNINF)
 		}
+		fmt.Println("Tolerance mode:", solver.TolMode, "  Feasible under tolerance modes:", strings.Join(solver.SatisfiedTolModes(Point), " "))
+		if lp.NumICols > 0 {
+			Frac := solver.GetFractionality(Point)
+			fmt.Println("Fractional integer columns:", Frac.NumFrac, "of", Frac.NumInt, "  max fractionality:", Frac.MaxFrac, "  MIP mode:", solver.MIPMode)
+		}
+		if solver.NumNumericalFails > 0 {
+			fmt.Println("CC runs and swarm points discarded because of numerical problems:",solver.NumNumericalFails)
+		}
 		fmt.Println()
-		
+	}
+	if solver.PrintLevel > 0 && *SimplexMode != "cold" {
 		// Summarize the results on updating of the incumbent
 		for i:=0; i<23; i++ {
 			if solver.NumUpdate[i] > 0 {
@@ -167,8 +219,310 @@ func main() {
 	//	fmt.Println("  Up:  ",solver.IncumbentUp)
 	//	fmt.Println("  Down:",solver.IncumbentDown)	
 
+	}
+	if *IIS && Status != 0 {
+		IISStart := solver.NIncumbentPt
+		if *SimplexMode == "cold" {
+			IISStart = Point
+			fmt.Println("\nLooking for an irreducible infeasible subset, starting from the simplex point")
+		} else {
+			fmt.Println("\nLooking for an irreducible infeasible subset, starting from the NINF incumbent (NINF", solver.NIncumbentNINF, ")")
+		}
+		Result, IISStatus := solver.FindIIS(IISStart)
+		switch IISStatus {
+		case 0:
+			solver.PrintIIS(Result)
+		case 1:
+			fmt.Println("The LP feasibility check finds the model feasible, so there is no IIS: CC did not reach a feasible point.")
+		default:
+			fmt.Println("IIS analysis abandoned: an LP feasibility check failed.")
+		}
+	}
+	if *SolFile != "" {
+		if solver.WriteSolution(*SolFile, Point, *SolFormat) == 0 {
+			fmt.Println("Solution written to", *SolFile)
+		}
 	}
 	fmt.Println("Finished", inputMPS)
 	os.Exit(0)
 
 }
+//=======================================================================================
+// Runs the bounded simplex, warm-started from Start (the CC incumbent) or cold if Start is nil,
+// and reports the outcome. Returns the simplex point and 0 if it is feasible, or Start and a
+// nonzero status if the simplex did not find a feasible point.
+func RunSimplex(Start []float64, Optimize bool) (Point []float64, Status int) {
+
+	if Start == nil {
+		fmt.Println("\nSolving with the bounded simplex from a cold start")
+	} else {
+		fmt.Println("\nBounded simplex warm-started from the CC incumbent")
+	}
+	SimplexStart := time.Now()
+	Result, SimplexStatus := solver.Simplex(solver.SIMPLEXOPTS{Start: Start, Optimize: Optimize})
+	fmt.Println(" ", Result.Iterations, "iterations (", Result.Phase1Its, "in phase 1 ),", Result.NumCrash, "crash columns,",
+		Result.NumRefacts, "refactorizations,", time.Since(SimplexStart).Seconds(), "s")
+	switch SimplexStatus {
+	case 0:
+		_, NINF, _, _, _, MaxViol, _ := solver.TestPoint(Result.Point)
+		fmt.Println("  LP feasible point found. NINF at the point:", NINF, " Maximum violation:", MaxViol)
+		if Optimize && lp.LP.ObjRow >= 0 {
+			fmt.Println("  Optimal objective:", Result.Objective)
+		}
+		if NINF > 0 && (lp.NumSCols > 0 || len(lp.LP.SOS) > 0 || len(solver.Constraints) > 0 || (solver.MIPMode && lp.NumICols > 0)) {
+			// The simplex works on the relaxation: semi-continuous columns over the hull of their domains,
+			// no integrality, no special ordered sets and no custom constraints
+			fmt.Println("  The point solves the relaxation only: semi-continuous or integer columns, special ordered sets or custom constraints are violated.")
+			return Result.Point, 1
+		}
+		return Result.Point, 0
+	case 1:
+		fmt.Println("  The model is infeasible: phase 1 ends with a sum of infeasibilities of", Result.SumInf)
+	case 2:
+		fmt.Println("  The objective is unbounded below. Feasible point kept.")
+		return Result.Point, 0
+	case 3:
+		fmt.Println("  Iteration limit reached. Sum of infeasibilities:", Result.SumInf)
+	case 5:
+		fmt.Println("  The model has quadratic rows: use CC instead.")
+	default:
+		fmt.Println("  Numerical trouble. Sum of infeasibilities:", Result.SumInf)
+	}
+	if Start == nil {
+		Start = Result.Point
+	}
+	if Start == nil {
+		// The simplex did not start: report the origin
+		Start = make([]float64, lp.NumCols)
+	}
+	return Start, 1
+}
+//=======================================================================================
+// The batch command: solves every model in the given directories and files with the
+// settings from the control panel and writes one CSV or JSON Lines record per model.
+// Returns the exit status for the program.
+func BatchCommand(Args []string) int {
+
+	var Opts bench.OPTIONS
+
+	Flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	OutFile := Flags.String("o", "results.csv", "results file")
+	Format := Flags.String("format", "", "csv or jsonl (default: from the results file extension)")
+	ListFile := Flags.String("list", "", "text file listing one model file per line")
+	Seed := Flags.Int64("seed", 0, "random number seed (0: seed from the clock)")
+	TimeLimit := Flags.Duration("timeout", 0, "calculation time limit per model, e.g. 10m (0: no limit)")
+	Resume := Flags.Bool("resume", false, "skip models already in the results file")
+	PrintLevel := Flags.Int("print", 0, "solver print level")
+	Jobs := Flags.Int("jobs", 1, "number of models to solve at the same time")
+	Accurate := Flags.Bool("accurate", lp.AccurateSums, "use compensated sums in the row evaluations")
+	Opts.TolMode = solver.TolMode
+	Flags.Var(&Opts.TolMode, "tolmode", "feasibility tolerance mode: absolute, relative or rownorm")
+	Flags.BoolVar(&Opts.MIP, "mip", false, "enforce integrality on the integer columns")
+	Flags.Var(&Opts.Round, "round", "rounding in the MIP mode: simple, random or ordered")
+	Flags.Usage = func() {
+		fmt.Println("Usage: CCLPv7 batch [options] directory|file ...")
+		Flags.PrintDefaults()
+	}
+	Flags.Parse(Args)
+
+	Files, Status := bench.ModelFiles(Flags.Args(), *ListFile)
+	if Status > 0 {
+		return 1
+	}
+	if len(Files) == 0 {
+		Flags.Usage()
+		return 1
+	}
+
+	Opts.Alpha = Alpha
+	Opts.Beta = Beta
+	Opts.MaxItns = MaxItns
+	Opts.MaxSwarmPts = MaxSwarmPts
+	Opts.Plinfy = plinfy
+	Opts.Featol = featol
+	Opts.Seed = *Seed
+	Opts.TimeLimit = *TimeLimit
+	Opts.Resume = *Resume
+	Opts.Jobs = *Jobs
+	Opts.Accurate = *Accurate
+	Opts.Format = *Format
+	if Opts.Format == "" {
+		Opts.Format = "csv"
+		if filepath.Ext(*OutFile) == ".jsonl" || filepath.Ext(*OutFile) == ".json" {
+			Opts.Format = "jsonl"
+		}
+	}
+	if Opts.Format != "csv" && Opts.Format != "jsonl" {
+		fmt.Println("Error: unknown results format", Opts.Format)
+		return 1
+	}
+	solver.PrintLevel = *PrintLevel
+
+	return bench.RunBatch(Files, *OutFile, Opts)
+}
+//=======================================================================================
+// Solves a single model for a batch run and prints its result record on the last
+// line. Not meant to be called by hand: the batch command starts it as a child process.
+func SolveModelCommand(Args []string) int {
+
+	var Opts bench.OPTIONS
+
+	Flags := flag.NewFlagSet(bench.ChildCommand, flag.ExitOnError)
+	Seed := Flags.Int64("seed", 0, "random number seed (0: seed from the clock)")
+	TimeLimit := Flags.Duration("timeout", 0, "calculation time limit (0: no limit)")
+	Procs := Flags.Int("procs", runtime.NumCPU(), "number of CPUs to use")
+	Accurate := Flags.Bool("accurate", false, "use compensated sums in the row evaluations")
+	Flags.Var(&Opts.TolMode, "tolmode", "feasibility tolerance mode: absolute, relative or rownorm")
+	Flags.BoolVar(&Opts.MIP, "mip", false, "enforce integrality on the integer columns")
+	Flags.Var(&Opts.Round, "round", "rounding in the MIP mode: simple, random or ordered")
+	Flags.IntVar(&solver.PrintLevel, "print", 0, "solver print level")
+	Flags.Parse(Args)
+	if Flags.NArg() != 1 {
+		fmt.Println("Usage: CCLPv7", bench.ChildCommand, "[options] file")
+		return 1
+	}
+
+	runtime.GOMAXPROCS(*Procs)
+	Opts.Alpha = Alpha
+	Opts.Beta = Beta
+	Opts.MaxItns = MaxItns
+	Opts.MaxSwarmPts = *Procs
+	Opts.Plinfy = plinfy
+	Opts.Featol = featol
+	Opts.Seed = *Seed
+	Opts.TimeLimit = *TimeLimit
+	Opts.Accurate = *Accurate
+
+	Result := bench.SolveModel(Flags.Arg(0), Opts)
+	Bytes, _ := json.Marshal(Result)
+	fmt.Println(bench.ResultPrefix + string(Bytes))
+	return 0
+}
+//=======================================================================================
+// The compare command: compares a new batch results file against a base one and flags the
+// models that got worse. Returns 2 if any regression is flagged, so scripts can check it.
+func CompareCommand(Args []string) int {
+
+	var Opts bench.COMPAREOPTS
+
+	Flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	NINFIncrease := Flags.Int("ninf", 0, "flag NINF increases larger than this")
+	SFDIncrease := Flags.Float64("sfd", 0.1, "flag SFD increases larger than this fraction of max(1, base SFD)")
+	TimeRatio := Flags.Float64("time", 1.5, "flag calculation time ratios (new/base) larger than this")
+	MinTime := Flags.Float64("mintime", 0.1, "raise times below this many seconds to it before taking ratios")
+	Flags.Usage = func() {
+		fmt.Println("Usage: CCLPv7 compare [options] base-results new-results")
+		Flags.PrintDefaults()
+	}
+	Flags.Parse(Args)
+	if Flags.NArg() != 2 {
+		Flags.Usage()
+		return 1
+	}
+
+	Opts.NINFIncrease = *NINFIncrease
+	Opts.SFDIncrease = *SFDIncrease
+	Opts.TimeRatio = *TimeRatio
+	Opts.MinTime = *MinTime
+	_, NumRegressions, Status := bench.Compare(Flags.Arg(0), Flags.Arg(1), Opts, os.Stdout)
+	if Status > 0 {
+		return 1
+	}
+	if NumRegressions > 0 {
+		return 2
+	}
+	return 0
+}
+//=======================================================================================
+// The profile command: builds performance profiles for calculation time to feasibility and
+// for final SFD from several batch results files, one per configuration, and writes each as
+// CSV data and an SVG plot. Returns the exit status for the program.
+func ProfileCommand(Args []string) int {
+
+	Flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	Prefix := Flags.String("o", "profile", "output file prefix: writes <prefix>_time.csv/.svg and <prefix>_sfd.csv/.svg")
+	MinTime := Flags.Float64("mintime", 0.01, "raise times below this many seconds to it before taking ratios")
+	SFDFloor := Flags.Float64("sfdfloor", 1.0e-6, "raise SFDs below this to it before taking ratios")
+	Flags.Usage = func() {
+		fmt.Println("Usage: CCLPv7 profile [options] results-file results-file ...")
+		Flags.PrintDefaults()
+	}
+	Flags.Parse(Args)
+	if Flags.NArg() < 2 {
+		Flags.Usage()
+		return 1
+	}
+
+	for _, Metric := range []string{"time", "sfd"} {
+		Floor := *MinTime
+		if Metric == "sfd" {
+			Floor = *SFDFloor
+		}
+		Prof, Status := bench.Profile(Flags.Args(), Metric, Floor)
+		if Status > 0 {
+			return 1
+		}
+		if Prof.WriteCSV(*Prefix+"_"+Metric+".csv") > 0 || Prof.WriteSVG(*Prefix+"_"+Metric+".svg") > 0 {
+			return 1
+		}
+		fmt.Println("Wrote", *Prefix+"_"+Metric+".csv", "and", *Prefix+"_"+Metric+".svg")
+	}
+	return 0
+}
+//=======================================================================================
+// The verify command: reads a model and a solution file from this or another solver and
+// checks the point with TestPoint, listing every violated row and bound by name. With -exact
+// the point is also certified in rational arithmetic, and that verdict decides the exit status.
+// Returns 0 if the point is feasible, 2 if it is not, 1 on errors.
+func VerifyCommand(Args []string) int {
+
+	Flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	Featol := Flags.Float64("featol", featol, "feasibility tolerance")
+	Exact := Flags.Bool("exact", false, "also certify the point in exact rational arithmetic from the MPS file's decimal data")
+	Flags.Var(&solver.TolMode, "tolmode", "feasibility tolerance mode: absolute, relative or rownorm")
+	ExactTol := Flags.String("tol", "0", "tolerance for -exact, as an exact decimal or fraction (e.g. 1e-9 or 1/1000000)")
+	Flags.Usage = func() {
+		fmt.Println("Usage: CCLPv7 verify [options] file.mps solution-file")
+		fmt.Println("Solution files: this program's text or JSON, CPLEX XML, Gurobi, HiGHS or GLPK raw (glpsol -w)")
+		Flags.PrintDefaults()
+	}
+	Flags.Parse(Args)
+	if Flags.NArg() != 2 {
+		Flags.Usage()
+		return 1
+	}
+
+	Tol, ok := new(big.Rat).SetString(*ExactTol)
+	if !ok || Tol.Sign() < 0 {
+		fmt.Println("Error: bad exact tolerance", *ExactTol)
+		return 1
+	}
+	lp.KeepExact = *Exact
+	if lp.ReadMPSFile(Flags.Arg(0), plinfy, *Featol) > 0 {
+		fmt.Println("Errors reading MPS file: exiting main program.")
+		return 1
+	}
+	solver.SetTolerances(plinfy, *Featol)
+	Point, Format, Status := solver.ReadSolution(Flags.Arg(1))
+	if Status > 0 {
+		return 1
+	}
+	fmt.Println("\nVerifying", Flags.Arg(1), "(", Format, "format ) with feasibility tolerance", *Featol, "(", solver.TolMode, "mode )")
+	solver.PrintViolations(Point)
+	if *Exact {
+		fmt.Println()
+		Cert, Status := solver.CertifyPoint(Point, Tol)
+		if Status > 0 {
+			return 1
+		}
+		solver.PrintCertificate(Cert)
+		if !Cert.Feasible {
+			return 2
+		}
+		return 0
+	}
+	if _, NINF, _, _, _, _, _ := solver.TestPoint(Point); NINF > 0 {
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,595 @@
+package bench
+
+// Runs a set of models through the solver and records one result per model. The results
+// are written as CSV or JSON Lines, one record per line, and each record is flushed to disk
+// as soon as it is known so that a crash part of the way through an overnight run loses at
+// most the models that were being solved.
+
+// The model and the solver state are package globals, so only one model can be solved per
+// process. The batch runner starts a child copy of the program per model (see ChildCommand),
+// which can be killed if it runs well past the time limit: the solver checks the limit only
+// between rounds. Several children may run at once, each with its share of the CPU cores.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"lp"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"solver"
+	"sync"
+	"time"
+)
+
+// Settings used for every model in a batch
+type OPTIONS struct {
+	Alpha       float64          // Feasibility distance tolerance
+	Beta        float64          // Movement tolerance
+	MaxItns     int              // Maximum CC iterations
+	MaxSwarmPts int              // Maximum number of points in a swarm
+	Plinfy      float64          // Plus infinity
+	Featol      float64          // Feasibility tolerance
+	Seed        int64            // Random number seed. 0: seed from the clock, a different seed per model
+	TimeLimit   time.Duration    // Calculation time limit per model. 0: no limit
+	Format      string           // Output format: "csv" or "jsonl"
+	Resume      bool             // true: skip the models already recorded in the output file
+	Jobs        int              // Number of models solved at the same time. 0 or 1: one at a time with every CPU
+	Accurate    bool             // Compensated sums in the row evaluations (lp.AccurateSums)
+	TolMode     solver.TOLMODE   // Tolerance mode of the feasibility oracle
+	MIP         bool             // Enforce integrality on the integer columns (solver.MIPMode)
+	Round       solver.ROUNDMODE // Rounding used in the MIP mode
+}
+
+// Command line command that solves a single model and prints its result, used for the child processes
+const ChildCommand = "solvemodel"
+
+// Prefix of the line on which a child process reports its result
+const ResultPrefix = "RESULT "
+
+// The result of solving one model
+type RESULT struct {
+	Model         string  `json:"model"`  // Model name: the file name without its extension
+	File          string  `json:"file"`   // Location of the MPS file
+	Status        string  `json:"status"` // feasible, fractional, notfound, timeout, numerical, readerror, killed or crashed
+	NINF          int     `json:"ninf"`
+	SFD           float64 `json:"sfd"`
+	Rounds        int     `json:"rounds"`
+	CCRuns        int     `json:"ccruns"`
+	ReadTime      float64 `json:"readtime"` // seconds
+	CalcTime      float64 `json:"calctime"` // seconds
+	LinProjSucc   int     `json:"linprojsucc"`
+	LinProjTries  int     `json:"linprojtries"`
+	LinProjImp    float64 `json:"linprojimp"` // average fractional improvement when the linear projection succeeds
+	QuadProjSucc  int     `json:"quadprojsucc"`
+	QuadProjTries int     `json:"quadprojtries"`
+	QuadProjImp   float64 `json:"quadprojimp"` // average fractional improvement when the quadratic projection succeeds
+	Seed          int64   `json:"seed"`
+	TolMode       string  `json:"tolmode"`       // tolerance mode of the feasibility oracle used by the solver
+	FeasibleModes string  `json:"feasiblemodes"` // tolerance modes the final point satisfies, separated by spaces
+	MIP           bool    `json:"mip"`           // integrality enforced
+	IntCols       int     `json:"intcols"`       // number of integer columns
+	NumFrac       int     `json:"numfrac"`       // integer columns more than featol from an integer at the final point
+	MaxFrac       float64 `json:"maxfrac"`       // largest distance of an integer column to the nearest integer
+}
+
+// Column titles for the CSV format, in the order written by CSVRecord
+var CSVHeader = []string{"model", "file", "status", "ninf", "sfd", "rounds", "ccruns", "readtime", "calctime",
+	"linprojsucc", "linprojtries", "linprojimp", "quadprojsucc", "quadprojtries", "quadprojimp", "seed", "tolmode", "feasiblemodes",
+	"mip", "intcols", "numfrac", "maxfrac"}
+
+//=======================================================================================
+// Expands the command line arguments into a sorted list of model files. Each argument can be
+// a directory (every regular file in it is used) or a single file. ListFile, if not empty,
+// names a text file holding one model location per line.
+// Status: 0(success), 1(problem reading a directory or the list file)
+func ModelFiles(Args []string, ListFile string) (Files []string, Status int) {
+
+	for _, Arg := range Args {
+		Info, err := os.Stat(Arg)
+		if err != nil {
+			fmt.Println("Error: cannot find", Arg)
+			return nil, 1
+		}
+		if !Info.IsDir() {
+			Files = append(Files, Arg)
+			continue
+		}
+		Entries, err := ioutil.ReadDir(Arg)
+		if err != nil {
+			fmt.Println("Error: cannot read directory", Arg)
+			return nil, 1
+		}
+		DirFiles := []string{}
+		for _, Entry := range Entries {
+			if Entry.Mode().IsRegular() {
+				DirFiles = append(DirFiles, filepath.Join(Arg, Entry.Name()))
+			}
+		}
+		sort.Strings(DirFiles)
+		Files = append(Files, DirFiles...)
+	}
+
+	if ListFile != "" {
+		f, err := os.Open(ListFile)
+		if err != nil {
+			fmt.Println("Error: cannot open model list", ListFile)
+			return nil, 1
+		}
+		defer f.Close()
+		Scanner := bufio.NewScanner(f)
+		for Scanner.Scan() {
+			Line := strings.TrimSpace(Scanner.Text())
+			if Line == "" || strings.HasPrefix(Line, "#") {
+				continue
+			}
+			Files = append(Files, Line)
+		}
+	}
+	return Files, 0
+}
+
+//=======================================================================================
+// Returns the model name used to match results across runs: the file name without its extension
+func ModelName(File string) string {
+	Name := filepath.Base(File)
+	return strings.TrimSuffix(Name, filepath.Ext(Name))
+}
+
+//=======================================================================================
+// Solves every model in Files with the given options and writes one record per model to OutFile.
+// If Opts.Resume is set, models already in OutFile are skipped, and the models that were being
+// solved when an earlier run crashed are recorded as crashed instead of being tried again.
+// Status: 0(success), 1(problem with the output file)
+func RunBatch(Files []string, OutFile string, Opts OPTIONS) (Status int) {
+
+	var Done map[string]bool
+	var Results []RESULT
+	var Result RESULT
+	var NumSkipped int
+
+	Progress := &progress{Name: OutFile + ".inprogress"}
+
+	Done = make(map[string]bool)
+	if Opts.Resume {
+		if _, err := os.Stat(OutFile); err == nil {
+			Results, Status = ReadResults(OutFile)
+			if Status > 0 {
+				return 1
+			}
+			for _, Result = range Results {
+				Done[Result.Model] = true
+			}
+		}
+	} else {
+		os.Remove(OutFile)
+	}
+
+	Out, Status := openResults(OutFile, Opts.Format)
+	if Status > 0 {
+		return 1
+	}
+	defer Out.Close()
+
+	// The models left in the progress file were running when the previous run crashed, so
+	// record them and move on
+	if Opts.Resume {
+		if Bytes, err := ioutil.ReadFile(Progress.Name); err == nil {
+			for _, File := range strings.Split(string(Bytes), "\n") {
+				File = strings.TrimSpace(File)
+				if File == "" || Done[ModelName(File)] {
+					continue
+				}
+				fmt.Println("Model", File, "was being solved when the last run stopped: recording it as crashed.")
+				Result = RESULT{Model: ModelName(File), File: File, Status: "crashed", Seed: Opts.Seed}
+				if writeResult(Out, Opts.Format, Result) > 0 {
+					return 1
+				}
+				Done[Result.Model] = true
+			}
+		}
+	}
+
+	Todo := []string{}
+	for _, File := range Files {
+		if Done[ModelName(File)] {
+			NumSkipped++
+			continue
+		}
+		Done[ModelName(File)] = true // so a model listed twice is solved once
+		Todo = append(Todo, File)
+	}
+
+	if Opts.Jobs > 1 {
+		if runParallel(Todo, Out, Opts, Progress) > 0 {
+			return 1
+		}
+	} else {
+		for i, File := range Todo {
+			fmt.Println("FILE:", File)
+			Progress.start(File)
+			Result = solveInChild(File, Opts, runtime.NumCPU())
+			Progress.finish(File)
+			if writeResult(Out, Opts.Format, Result) > 0 {
+				return 1
+			}
+			fmt.Println("-------------------Finished number", i, "of", len(Todo)-1, "(", Result.Status, ")--------------------------------")
+		}
+	}
+	os.Remove(Progress.Name)
+	if NumSkipped > 0 {
+		fmt.Println(NumSkipped, "models skipped because they were already in", OutFile)
+	}
+	fmt.Println("DONE!")
+	return 0
+}
+
+//=======================================================================================
+// Solves the models in Files with Opts.Jobs child processes running at once. The CPU cores are
+// split evenly between the children, and each child uses its share for its own CC runs.
+// Results are written in the order of Files, whatever order the children finish in, and the
+// models running at any moment are kept in Progress.
+// Status: 0(success), 1(problem writing the results)
+func runParallel(Files []string, Out *os.File, Opts OPTIONS, Progress *progress) (Status int) {
+
+	type JOBDONE struct {
+		Index  int
+		Result RESULT
+	}
+	var NextToWrite int
+
+	Procs := runtime.NumCPU() / Opts.Jobs
+	if Procs < 1 {
+		Procs = 1
+	}
+	fmt.Println("Solving", len(Files), "models,", Opts.Jobs, "at a time with", Procs, "CPUs each.")
+
+	Jobs := make(chan int)
+	chDone := make(chan JOBDONE)
+	for w := 0; w < Opts.Jobs; w++ {
+		go func() {
+			for i := range Jobs {
+				Progress.start(Files[i])
+				Result := solveInChild(Files[i], Opts, Procs)
+				Progress.finish(Files[i])
+				chDone <- JOBDONE{i, Result}
+			}
+		}()
+	}
+	go func() {
+		for i := range Files {
+			Jobs <- i
+		}
+		close(Jobs)
+	}()
+
+	Finished := make(map[int]RESULT)
+	for n := 0; n < len(Files); n++ {
+		Job := <-chDone
+		fmt.Println("-------------------Finished", Files[Job.Index], "(", Job.Result.Status, ")", n+1, "of", len(Files), "done")
+		Finished[Job.Index] = Job.Result
+		// Write out every result that is now next in line
+		for {
+			Result, ok := Finished[NextToWrite]
+			if !ok {
+				break
+			}
+			if writeResult(Out, Opts.Format, Result) > 0 {
+				return 1
+			}
+			delete(Finished, NextToWrite)
+			NextToWrite++
+		}
+	}
+	return 0
+}
+
+//=======================================================================================
+// The models being solved, kept one per line in a progress file so that if the batch process
+// itself dies, the next run with Resume can record them as crashed. A model leaves the file
+// when its child exits, so one whose result was not yet written is tried again instead.
+type progress struct {
+	Name    string // the progress file
+	Lock    sync.Mutex
+	Running []string // model files being solved
+}
+
+//=======================================================================================
+func (p *progress) start(File string) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	p.Running = append(p.Running, File)
+	p.write()
+}
+
+//=======================================================================================
+func (p *progress) finish(File string) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	for k := range p.Running {
+		if p.Running[k] == File {
+			p.Running = append(p.Running[:k], p.Running[k+1:]...)
+			break
+		}
+	}
+	p.write()
+}
+
+//=======================================================================================
+// Rewrites the progress file. The caller holds the lock.
+func (p *progress) write() {
+	ioutil.WriteFile(p.Name, []byte(strings.Join(p.Running, "\n")+"\n"), 0644)
+}
+
+//=======================================================================================
+// Solves one model in a child copy of this program using Procs CPUs. The child is killed if it
+// runs well past the time limit and recorded as killed, with no incumbent, and a child that dies
+// without reporting is recorded as crashed. When the models are solved one at a time with
+// printing on, the child's output is shown as it runs.
+func solveInChild(File string, Opts OPTIONS, Procs int) (Result RESULT) {
+
+	Result = RESULT{Model: ModelName(File), File: File, Status: "crashed", Seed: Opts.Seed}
+
+	Exe, err := os.Executable()
+	if err != nil {
+		fmt.Println("Error: cannot locate this program to start a child process:", err)
+		return Result
+	}
+	Cmd := exec.Command(Exe, ChildCommand, "-seed", strconv.FormatInt(Opts.Seed, 10),
+		"-timeout", Opts.TimeLimit.String(), "-procs", strconv.Itoa(Procs), "-accurate="+strconv.FormatBool(Opts.Accurate),
+		"-tolmode", Opts.TolMode.String(), "-mip="+strconv.FormatBool(Opts.MIP), "-round", Opts.Round.String(),
+		"-print", strconv.Itoa(solver.PrintLevel), File)
+	var Output bytes.Buffer
+	Cmd.Stdout = &Output
+	if Opts.Jobs <= 1 && solver.PrintLevel > 0 {
+		Cmd.Stdout = io.MultiWriter(&Output, os.Stdout)
+	}
+	Cmd.Stderr = Cmd.Stdout
+	if err = Cmd.Start(); err != nil {
+		fmt.Println("Error: cannot start a child process for", File, ":", err)
+		return Result
+	}
+
+	// Hard limit: the solver checks the time limit only between rounds, so allow some slack
+	chExit := make(chan error, 1)
+	go func() { chExit <- Cmd.Wait() }()
+	var Deadline <-chan time.Time
+	if Opts.TimeLimit > 0 {
+		Deadline = time.After(2*Opts.TimeLimit + time.Minute)
+	}
+	select {
+	case <-chExit:
+	case <-Deadline:
+		Cmd.Process.Kill()
+		<-chExit
+		Result.Status = "killed" // no result came back, unlike the solver's own "timeout"
+		Result.CalcTime = Opts.TimeLimit.Seconds()
+		return Result
+	}
+
+	// The result is on the last line that starts with ResultPrefix
+	Lines := strings.Split(Output.String(), "\n")
+	for i := len(Lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(Lines[i], ResultPrefix) {
+			json.Unmarshal([]byte(strings.TrimPrefix(Lines[i], ResultPrefix)), &Result)
+			break
+		}
+	}
+	return Result
+}
+
+//=======================================================================================
+// Reads in and solves a single model, returning its result record
+func SolveModel(File string, Opts OPTIONS) (Result RESULT) {
+
+	var Status int
+
+	Result.Model = ModelName(File)
+	Result.File = File
+	Result.Seed = Opts.Seed
+
+	lp.AccurateSums = Opts.Accurate // before reading: the gradient lengths are computed by the reader
+	StartTime := time.Now()
+	Status = lp.ReadMPSFile(File, Opts.Plinfy, Opts.Featol)
+	Result.ReadTime = time.Since(StartTime).Seconds()
+	if Status > 0 {
+		fmt.Println("  Errors reading MPS file. Aborting this model.")
+		Result.Status = "readerror"
+		return Result
+	}
+
+	solver.RandomSeed = Opts.Seed
+	solver.TimeLimit = Opts.TimeLimit
+	solver.TolMode = Opts.TolMode
+	Result.TolMode = Opts.TolMode.String()
+	solver.MIPMode = Opts.MIP
+	solver.RoundMode = Opts.Round
+	Result.MIP = Opts.MIP
+	CalculationStartTime := time.Now()
+	Point, Status := solver.Solve(Opts.Alpha, Opts.Beta, Opts.MaxItns, Opts.MaxSwarmPts, Opts.Plinfy, Opts.Featol)
+	Result.CalcTime = time.Since(CalculationStartTime).Seconds()
+
+	switch Status {
+	case 0:
+		Result.Status = "feasible"
+	case 2:
+		Result.Status = "numerical"
+	case 3:
+		Result.Status = "timeout"
+	default:
+		Result.Status = "notfound"
+	}
+	Result.NINF = solver.IncumbentNINF
+	Result.SFD = solver.IncumbentSFD
+	Result.Rounds = solver.FinalBox + 1
+	Result.CCRuns = solver.NumCCRuns
+	Result.LinProjSucc = solver.LinProjSucceeds
+	Result.LinProjTries = solver.LinProjSucceeds + solver.LinProjFails
+	if solver.LinProjSucceeds > 0 {
+		Result.LinProjImp = solver.LinProjFrac / float64(solver.LinProjSucceeds)
+	}
+	Result.QuadProjSucc = solver.QuadProjSucceeds
+	Result.QuadProjTries = solver.QuadProjSucceeds + solver.QuadProjFails
+	if solver.QuadProjSucceeds > 0 {
+		Result.QuadProjImp = solver.QuadProjFrac / float64(solver.QuadProjSucceeds)
+	}
+	Result.Seed = solver.SeedUsed
+	if len(Point) == lp.LP.NumCols {
+		Result.FeasibleModes = strings.Join(solver.SatisfiedTolModes(Point), " ")
+		Frac := solver.GetFractionality(Point)
+		Result.IntCols, Result.NumFrac, Result.MaxFrac = Frac.NumInt, Frac.NumFrac, Frac.MaxFrac
+		if Result.Status == "feasible" && Frac.NumFrac > 0 {
+			// Feasible for the LP relaxation only: not a solution of the MIP
+			Result.Status = "fractional"
+		}
+	}
+	return Result
+}
+
+//=======================================================================================
+// Opens the results file for appending, writing the CSV header if the file is new
+// Status: 0(success), 1(cannot open the file)
+func openResults(OutFile string, Format string) (Out *os.File, Status int) {
+
+	Out, err := os.OpenFile(OutFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Println("Error: cannot open results file", OutFile)
+		return nil, 1
+	}
+	if Format == "csv" {
+		Info, _ := Out.Stat()
+		if Info.Size() == 0 {
+			w := csv.NewWriter(Out)
+			w.Write(CSVHeader)
+			w.Flush()
+		}
+	}
+	return Out, 0
+}
+
+//=======================================================================================
+// Appends one record to the results file and flushes it to disk
+// Status: 0(success), 1(write failed)
+func writeResult(Out *os.File, Format string, Result RESULT) (Status int) {
+
+	if Format == "csv" {
+		w := csv.NewWriter(Out)
+		w.Write(Result.CSVRecord())
+		w.Flush()
+		if w.Error() != nil {
+			fmt.Println("Error writing results:", w.Error())
+			return 1
+		}
+	} else {
+		Bytes, _ := json.Marshal(Result)
+		if _, err := Out.Write(append(Bytes, '\n')); err != nil {
+			fmt.Println("Error writing results:", err)
+			return 1
+		}
+	}
+	Out.Sync()
+	return 0
+}
+
+//=======================================================================================
+// Returns the fields of a result in the order of CSVHeader
+func (r RESULT) CSVRecord() []string {
+	f := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	return []string{r.Model, r.File, r.Status, strconv.Itoa(r.NINF), f(r.SFD), strconv.Itoa(r.Rounds),
+		strconv.Itoa(r.CCRuns), f(r.ReadTime), f(r.CalcTime), strconv.Itoa(r.LinProjSucc), strconv.Itoa(r.LinProjTries),
+		f(r.LinProjImp), strconv.Itoa(r.QuadProjSucc), strconv.Itoa(r.QuadProjTries), f(r.QuadProjImp),
+		strconv.FormatInt(r.Seed, 10), r.TolMode, r.FeasibleModes,
+		strconv.FormatBool(r.MIP), strconv.Itoa(r.IntCols), strconv.Itoa(r.NumFrac), f(r.MaxFrac)}
+}
+
+//=======================================================================================
+// Reads a results file written by RunBatch. The format is recognized from the content:
+// JSON Lines if the first non-blank character is "{", otherwise CSV with a header line.
+// Status: 0(success), 1(cannot read or parse the file)
+func ReadResults(FileName string) (Results []RESULT, Status int) {
+
+	Bytes, err := ioutil.ReadFile(FileName)
+	if err != nil {
+		fmt.Println("Error: cannot read results file", FileName)
+		return nil, 1
+	}
+	Text := strings.TrimSpace(string(Bytes))
+	if Text == "" {
+		return nil, 0
+	}
+
+	if strings.HasPrefix(Text, "{") {
+		for iline, Line := range strings.Split(Text, "\n") {
+			if strings.TrimSpace(Line) == "" {
+				continue
+			}
+			var Result RESULT
+			if err := json.Unmarshal([]byte(Line), &Result); err != nil {
+				fmt.Println("Error: cannot parse line", iline+1, "of", FileName)
+				return nil, 1
+			}
+			Results = append(Results, Result)
+		}
+		return Results, 0
+	}
+
+	Reader := csv.NewReader(strings.NewReader(Text))
+	Reader.FieldsPerRecord = -1 // files written before a column was added have shorter records
+	Records, err := Reader.ReadAll()
+	if err != nil || len(Records) == 0 {
+		fmt.Println("Error: cannot parse CSV results file", FileName)
+		return nil, 1
+	}
+	Column := make(map[string]int)
+	for i, Title := range Records[0] {
+		Column[Title] = i
+	}
+	if _, ok := Column["model"]; !ok {
+		fmt.Println("Error: no model column in", FileName)
+		return nil, 1
+	}
+	for _, Record := range Records[1:] {
+		Get := func(Title string) string {
+			if i, ok := Column[Title]; ok && i < len(Record) {
+				return Record[i]
+			}
+			return ""
+		}
+		Int := func(Title string) int { v, _ := strconv.Atoi(Get(Title)); return v }
+		Float := func(Title string) float64 { v, _ := strconv.ParseFloat(Get(Title), 64); return v }
+		var Result RESULT
+		Result.Model = Get("model")
+		Result.File = Get("file")
+		Result.Status = Get("status")
+		Result.NINF = Int("ninf")
+		Result.SFD = Float("sfd")
+		Result.Rounds = Int("rounds")
+		Result.CCRuns = Int("ccruns")
+		Result.ReadTime = Float("readtime")
+		Result.CalcTime = Float("calctime")
+		Result.LinProjSucc = Int("linprojsucc")
+		Result.LinProjTries = Int("linprojtries")
+		Result.LinProjImp = Float("linprojimp")
+		Result.QuadProjSucc = Int("quadprojsucc")
+		Result.QuadProjTries = Int("quadprojtries")
+		Result.QuadProjImp = Float("quadprojimp")
+		Result.Seed, _ = strconv.ParseInt(Get("seed"), 10, 64)
+		Result.TolMode = Get("tolmode")
+		Result.FeasibleModes = Get("feasiblemodes")
+		Result.MIP, _ = strconv.ParseBool(Get("mip"))
+		Result.IntCols = Int("intcols")
+		Result.NumFrac = Int("numfrac")
+		Result.MaxFrac = Float("maxfrac")
+		Results = append(Results, Result)
+	}
+	return Results, 0
+}
@@ -0,0 +1,179 @@
+package bench
+
+// Compares two batch result files model by model, so that a change to a CC variant or a
+// tolerance can be judged an improvement or a regression.
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Thresholds above which a change is flagged as a regression
+type COMPAREOPTS struct {
+	NINFIncrease int     // NINF increase (absolute) that is flagged
+	SFDIncrease  float64 // SFD increase, relative to max(1, base SFD), that is flagged
+	TimeRatio    float64 // Calculation time ratio (new/base) that is flagged
+	MinTime      float64 // Times below this (seconds) are raised to it before taking ratios, to ignore timer noise
+}
+
+// The comparison for one model found in both files
+type MODELDIFF struct {
+	Model       string
+	BaseStatus  string
+	NewStatus   string
+	NINFDelta   int     // new - base
+	SFDDelta    float64 // new - base
+	TimeRatio   float64 // new/base calculation time
+	Regressions []string
+}
+
+//=======================================================================================
+// Compares the results in BaseFile (the reference run) against NewFile and writes a report to w.
+// Status: 0(success), 1(cannot read one of the files)
+func Compare(BaseFile, NewFile string, Opts COMPAREOPTS, w io.Writer) (Diffs []MODELDIFF, NumRegressions int, Status int) {
+
+	var Diff MODELDIFF
+	var SumLogRatio, SumLogRatioSolved float64
+	var NumRatios, NumRatiosSolved int
+	var NumNewlySolved, NumNewlyUnsolved int
+	var OnlyBase, OnlyNew []string
+	NoIncumbent := make(map[string]int) // models left out of the time ratios, by the status of the run without an incumbent
+
+	BaseResults, Status := ReadResults(BaseFile)
+	if Status > 0 {
+		return nil, 0, 1
+	}
+	NewResults, Status := ReadResults(NewFile)
+	if Status > 0 {
+		return nil, 0, 1
+	}
+	Base := make(map[string]RESULT)
+	for _, Result := range BaseResults {
+		Base[Result.Model] = Result
+	}
+	New := make(map[string]RESULT)
+	for _, Result := range NewResults {
+		New[Result.Model] = Result
+	}
+	for Model := range Base {
+		if _, ok := New[Model]; !ok {
+			OnlyBase = append(OnlyBase, Model)
+		}
+	}
+	sort.Strings(OnlyBase)
+
+	// Go through the models in the order of the new file
+	for _, NewResult := range NewResults {
+		BaseResult, ok := Base[NewResult.Model]
+		if !ok {
+			OnlyNew = append(OnlyNew, NewResult.Model)
+			continue
+		}
+		Diff = MODELDIFF{Model: NewResult.Model, BaseStatus: BaseResult.Status, NewStatus: NewResult.Status}
+		Diff.NINFDelta = NewResult.NINF - BaseResult.NINF
+		Diff.SFDDelta = NewResult.SFD - BaseResult.SFD
+		Diff.TimeRatio = math.Max(NewResult.CalcTime, Opts.MinTime) / math.Max(BaseResult.CalcTime, Opts.MinTime)
+
+		BaseSolved := BaseResult.Status == "feasible"
+		NewSolved := NewResult.Status == "feasible"
+		if BaseSolved && !NewSolved {
+			NumNewlyUnsolved++
+			Diff.Regressions = append(Diff.Regressions, "no longer solved")
+		}
+		if !BaseSolved && NewSolved {
+			NumNewlySolved++
+		}
+		Comparable := HasIncumbent(BaseResult) && HasIncumbent(NewResult)
+		if !Comparable {
+			// Nothing to compare the NINF, SFD and time against
+			Diff.NINFDelta = 0
+			Diff.SFDDelta = 0
+			if !HasIncumbent(BaseResult) {
+				NoIncumbent[BaseResult.Status]++
+			} else {
+				NoIncumbent[NewResult.Status]++
+			}
+		}
+		if Diff.NINFDelta > Opts.NINFIncrease {
+			Diff.Regressions = append(Diff.Regressions, fmt.Sprintf("NINF up by %d", Diff.NINFDelta))
+		}
+		if Diff.SFDDelta > Opts.SFDIncrease*math.Max(1.0, BaseResult.SFD) {
+			Diff.Regressions = append(Diff.Regressions, fmt.Sprintf("SFD up by %.6g", Diff.SFDDelta))
+		}
+		if Diff.TimeRatio > Opts.TimeRatio {
+			Diff.Regressions = append(Diff.Regressions, fmt.Sprintf("time ratio %.3g", Diff.TimeRatio))
+		}
+		if len(Diff.Regressions) > 0 {
+			NumRegressions++
+		}
+
+		if Comparable && Diff.TimeRatio > 0 && !math.IsInf(Diff.TimeRatio, 0) {
+			SumLogRatio = SumLogRatio + math.Log(Diff.TimeRatio)
+			NumRatios++
+			if BaseSolved && NewSolved {
+				SumLogRatioSolved = SumLogRatioSolved + math.Log(Diff.TimeRatio)
+				NumRatiosSolved++
+			}
+		}
+		Diffs = append(Diffs, Diff)
+	}
+
+	// Write the report
+	fmt.Fprintln(w, "COMPARISON of", NewFile, "against", BaseFile)
+	fmt.Fprintf(w, "%-20s %-10s %-10s %8s %14s %10s  %s\n", "Model", "Base", "New", "dNINF", "dSFD", "TimeRatio", "Flags")
+	for _, Diff = range Diffs {
+		Flags := ""
+		for i, Reason := range Diff.Regressions {
+			if i > 0 {
+				Flags = Flags + "; "
+			}
+			Flags = Flags + Reason
+		}
+		if Flags != "" {
+			Flags = "REGRESSION: " + Flags
+		} else if Diff.BaseStatus != "feasible" && Diff.NewStatus == "feasible" {
+			Flags = "newly solved"
+		}
+		fmt.Fprintf(w, "%-20s %-10s %-10s %8d %14.6g %10.3f  %s\n", Diff.Model, Diff.BaseStatus, Diff.NewStatus,
+			Diff.NINFDelta, Diff.SFDDelta, Diff.TimeRatio, Flags)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, len(Diffs), "models in both files")
+	if len(OnlyBase) > 0 {
+		fmt.Fprintln(w, len(OnlyBase), "models only in", BaseFile, ":", OnlyBase)
+	}
+	if len(OnlyNew) > 0 {
+		fmt.Fprintln(w, len(OnlyNew), "models only in", NewFile, ":", OnlyNew)
+	}
+	fmt.Fprintln(w, NumNewlySolved, "newly solved,", NumNewlyUnsolved, "no longer solved")
+	if len(NoIncumbent) > 0 {
+		var Statuses []string
+		NumLeftOut := 0
+		for Stat, Num := range NoIncumbent {
+			Statuses = append(Statuses, fmt.Sprintf("%d %s", Num, Stat))
+			NumLeftOut = NumLeftOut + Num
+		}
+		sort.Strings(Statuses)
+		fmt.Fprintln(w, NumLeftOut, "models without an incumbent in one of the runs, left out of the time ratios:", strings.Join(Statuses, ", "))
+	}
+	if NumRatios > 0 {
+		fmt.Fprintln(w, "Geometric mean time ratio (new/base), models with an incumbent in both runs:", math.Exp(SumLogRatio/float64(NumRatios)))
+	}
+	if NumRatiosSolved > 0 {
+		fmt.Fprintln(w, "Geometric mean time ratio (new/base), models solved by both:", math.Exp(SumLogRatioSolved/float64(NumRatiosSolved)))
+	}
+	fmt.Fprintln(w, NumRegressions, "models flagged as regressions")
+	return Diffs, NumRegressions, 0
+}
+
+//=======================================================================================
+// True if the run got as far as an incumbent point, so its NINF and SFD mean something. A run
+// that was killed, crashed or failed to read the model has none, and neither has a run that
+// stopped before its first point, whose NINF is still the initial math.MaxInt32.
+func HasIncumbent(Result RESULT) bool {
+	Stopped := Result.Status == "feasible" || Result.Status == "fractional" || Result.Status == "notfound" || Result.Status == "timeout"
+	return Stopped && Result.NINF < math.MaxInt32
+}
@@ -0,0 +1,229 @@
+package bench
+
+// Dolan-More performance profiles over several batch result files, one file per configuration.
+// For each model the cost of a configuration is divided by the best cost any configuration got
+// on that model; the profile of a configuration is the fraction of models whose ratio is at most
+// Tau, as a function of Tau. Failures have an infinite ratio, so they never count.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// One performance profile: a step function per configuration
+type PROFILE struct {
+	Metric    string      // "time" or "sfd"
+	Configs   []string    // configuration names, from the result file names
+	Taus      []float64   // break points, ascending, starting at 1
+	Rho       [][]float64 // Rho[iconfig][itau]: fraction of models with ratio <= Taus[itau]
+	NumModels int
+}
+
+var ProfileColours = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#17becf"}
+
+//=======================================================================================
+// The cost of one result under the given metric, or +Inf if the run failed on that metric.
+// Time: calculation time to a feasible point. SFD: the final SFD. Costs are raised to Floor
+// so that zero times and zero SFDs still give finite ratios.
+func ProfileCost(Result RESULT, Metric string, Floor float64) float64 {
+	switch Metric {
+	case "time":
+		if Result.Status != "feasible" {
+			return math.Inf(1)
+		}
+		return math.Max(Result.CalcTime, Floor)
+	case "sfd":
+		if !HasIncumbent(Result) {
+			return math.Inf(1)
+		}
+		return math.Max(Result.SFD, Floor)
+	}
+	return math.Inf(1)
+}
+
+//=======================================================================================
+// Builds the performance profile of the given result files for one metric. A model missing
+// from a file counts as a failure for that configuration.
+// Status: 0(success), 1(cannot read a file), 2(unknown metric)
+func Profile(Files []string, Metric string, Floor float64) (Prof PROFILE, Status int) {
+
+	if Metric != "time" && Metric != "sfd" {
+		fmt.Println("Error: unknown performance profile metric", Metric)
+		return Prof, 2
+	}
+	Prof.Metric = Metric
+
+	// Read the results and collect the models, in order of first appearance
+	var Models []string
+	Seen := make(map[string]bool)
+	Costs := make([]map[string]float64, len(Files))
+	for ifile, File := range Files {
+		Results, Status := ReadResults(File)
+		if Status > 0 {
+			return Prof, 1
+		}
+		Config := ModelName(File)
+		for _, Other := range Prof.Configs {
+			if Other == Config {
+				Config = File // e.g. run1.csv and run1.jsonl
+			}
+		}
+		Prof.Configs = append(Prof.Configs, Config)
+		Costs[ifile] = make(map[string]float64)
+		for _, Result := range Results {
+			Costs[ifile][Result.Model] = ProfileCost(Result, Metric, Floor)
+			if !Seen[Result.Model] {
+				Seen[Result.Model] = true
+				Models = append(Models, Result.Model)
+			}
+		}
+	}
+	Prof.NumModels = len(Models)
+
+	// Performance ratios
+	Ratios := make([][]float64, len(Files))
+	var AllRatios []float64
+	for _, Model := range Models {
+		Best := math.Inf(1)
+		for ifile := range Files {
+			if Cost, ok := Costs[ifile][Model]; ok && Cost < Best {
+				Best = Cost
+			}
+		}
+		for ifile := range Files {
+			Ratio := math.Inf(1)
+			if Cost, ok := Costs[ifile][Model]; ok && !math.IsInf(Best, 1) {
+				Ratio = Cost / Best
+			}
+			Ratios[ifile] = append(Ratios[ifile], Ratio)
+			if !math.IsInf(Ratio, 1) {
+				AllRatios = append(AllRatios, Ratio)
+			}
+		}
+	}
+
+	// The curves only change at the finite ratios
+	sort.Float64s(AllRatios)
+	Prof.Taus = append(Prof.Taus, 1.0)
+	for _, Ratio := range AllRatios {
+		if Ratio > Prof.Taus[len(Prof.Taus)-1] {
+			Prof.Taus = append(Prof.Taus, Ratio)
+		}
+	}
+	Prof.Rho = make([][]float64, len(Files))
+	for ifile := range Files {
+		sort.Float64s(Ratios[ifile])
+		Prof.Rho[ifile] = make([]float64, len(Prof.Taus))
+		icount := 0
+		for itau, Tau := range Prof.Taus {
+			for icount < len(Ratios[ifile]) && Ratios[ifile][icount] <= Tau {
+				icount++
+			}
+			if Prof.NumModels > 0 {
+				Prof.Rho[ifile][itau] = float64(icount) / float64(Prof.NumModels)
+			}
+		}
+	}
+	return Prof, 0
+}
+
+//=======================================================================================
+// Writes the profile as CSV: a tau column then one column per configuration.
+// Status: 0(success), 1(cannot write the file)
+func (Prof PROFILE) WriteCSV(FileName string) int {
+
+	File, err := os.Create(FileName)
+	if err != nil {
+		fmt.Println("Error: cannot create performance profile file", FileName, ":", err)
+		return 1
+	}
+	defer File.Close()
+	Writer := csv.NewWriter(File)
+	Writer.Write(append([]string{"tau"}, Prof.Configs...))
+	for itau, Tau := range Prof.Taus {
+		Record := []string{strconv.FormatFloat(Tau, 'g', 8, 64)}
+		for iconfig := range Prof.Configs {
+			Record = append(Record, strconv.FormatFloat(Prof.Rho[iconfig][itau], 'g', 6, 64))
+		}
+		Writer.Write(Record)
+	}
+	Writer.Flush()
+	if err = Writer.Error(); err != nil {
+		fmt.Println("Error: cannot write performance profile file", FileName, ":", err)
+		return 1
+	}
+	return 0
+}
+
+//=======================================================================================
+// Writes the profile as a self-contained SVG plot with log2(tau) along the x axis.
+// Status: 0(success), 1(cannot write the file)
+func (Prof PROFILE) WriteSVG(FileName string) int {
+
+	const Width, Height = 640.0, 420.0
+	const Left, Right, Top, Bottom = 60.0, 160.0, 40.0, 50.0
+	PlotW := Width - Left - Right
+	PlotH := Height - Top - Bottom
+
+	// x range: log2 of the largest tau, with a bit of room so the final steps show
+	MaxLog := math.Ceil(math.Log2(Prof.Taus[len(Prof.Taus)-1]) * 1.05)
+	if MaxLog < 1 {
+		MaxLog = 1
+	}
+	X := func(Tau float64) float64 { return Left + PlotW*math.Log2(Tau)/MaxLog }
+	Y := func(Rho float64) float64 { return Top + PlotH*(1-Rho) }
+
+	File, err := os.Create(FileName)
+	if err != nil {
+		fmt.Println("Error: cannot create performance profile plot", FileName, ":", err)
+		return 1
+	}
+	defer File.Close()
+
+	Title := "Performance profile: calculation time to feasibility"
+	if Prof.Metric == "sfd" {
+		Title = "Performance profile: final SFD"
+	}
+	fmt.Fprintf(File, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%g\" font-family=\"sans-serif\" font-size=\"12\">\n", Width, Height)
+	fmt.Fprintf(File, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+	fmt.Fprintf(File, "<text x=\"%g\" y=\"20\" text-anchor=\"middle\" font-size=\"14\">%s (%d models)</text>\n", Left+PlotW/2, Title, Prof.NumModels)
+
+	// Axes, grid and tick labels
+	fmt.Fprintf(File, "<rect x=\"%g\" y=\"%g\" width=\"%g\" height=\"%g\" fill=\"none\" stroke=\"black\"/>\n", Left, Top, PlotW, PlotH)
+	for i := 0; i <= 10; i = i + 2 {
+		Rho := float64(i) / 10
+		fmt.Fprintf(File, "<line x1=\"%g\" y1=\"%.2f\" x2=\"%g\" y2=\"%.2f\" stroke=\"#ddd\"/>\n", Left, Y(Rho), Left+PlotW, Y(Rho))
+		fmt.Fprintf(File, "<text x=\"%g\" y=\"%.2f\" text-anchor=\"end\">%.1f</text>\n", Left-6, Y(Rho)+4, Rho)
+	}
+	Step := math.Ceil(MaxLog / 10)
+	for L := 0.0; L <= MaxLog; L = L + Step {
+		x := Left + PlotW*L/MaxLog
+		fmt.Fprintf(File, "<line x1=\"%.2f\" y1=\"%g\" x2=\"%.2f\" y2=\"%g\" stroke=\"#ddd\"/>\n", x, Top, x, Top+PlotH)
+		fmt.Fprintf(File, "<text x=\"%.2f\" y=\"%g\" text-anchor=\"middle\">%g</text>\n", x, Top+PlotH+16, math.Pow(2, L))
+	}
+	fmt.Fprintf(File, "<text x=\"%g\" y=\"%g\" text-anchor=\"middle\">tau (log2 scale)</text>\n", Left+PlotW/2, Height-10)
+	fmt.Fprintf(File, "<text transform=\"translate(16 %g) rotate(-90)\" text-anchor=\"middle\">fraction of models</text>\n", Top+PlotH/2)
+
+	// One step function per configuration, with a legend entry
+	for iconfig, Config := range Prof.Configs {
+		Colour := ProfileColours[iconfig%len(ProfileColours)]
+		Points := fmt.Sprintf("%.2f,%.2f", X(1), Y(Prof.Rho[iconfig][0]))
+		for itau := 1; itau < len(Prof.Taus); itau++ {
+			Points = Points + fmt.Sprintf(" %.2f,%.2f %.2f,%.2f", X(Prof.Taus[itau]), Y(Prof.Rho[iconfig][itau-1]),
+				X(Prof.Taus[itau]), Y(Prof.Rho[iconfig][itau]))
+		}
+		Points = Points + fmt.Sprintf(" %.2f,%.2f", Left+PlotW, Y(Prof.Rho[iconfig][len(Prof.Taus)-1]))
+		fmt.Fprintf(File, "<polyline fill=\"none\" stroke=\"%s\" stroke-width=\"2\" points=\"%s\"/>\n", Colour, Points)
+		yLegend := Top + 10 + 20*float64(iconfig)
+		fmt.Fprintf(File, "<line x1=\"%g\" y1=\"%g\" x2=\"%g\" y2=\"%g\" stroke=\"%s\" stroke-width=\"2\"/>\n",
+			Left+PlotW+10, yLegend, Left+PlotW+30, yLegend, Colour)
+		fmt.Fprintf(File, "<text x=\"%g\" y=\"%g\">%s</text>\n", Left+PlotW+36, yLegend+4, html.EscapeString(Config))
+	}
+	fmt.Fprintln(File, "</svg>")
+	return 0
+}
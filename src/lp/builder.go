@@ -0,0 +1,214 @@
+package lp
+
+// Building a model in memory instead of reading an MPS file. NewModel starts an empty model,
+// AddColumn, AddRow, SetCoefficient and SetObjective fill it in, and EndModel does what the
+// end of ReadMPSFile does: it picks the objective row, checks for empty rows and columns and
+// calls GetStatistics, which builds the element lists' derived data (gradient lengths, counts,
+// the CSR/CSC arrays). Row and column bounds follow the reader's conventions, so a model built
+// here is the same as one read from the equivalent MPS file. Names must be unique.
+// With KeepExact set, the exact data is taken from the shortest decimal form of each value,
+// as if the model had been written to an MPS file and read back.
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var colIndex, rowIndex map[string]int // Column and row numbers by name. nil: to be rebuilt
+
+//=============================================================================================
+// Starts a new, empty model, like reading an MPS file with the given infinity and tolerance
+func NewModel(Name string, plinfy float64, featol float64) {
+	_ = EmptyMPS()
+	Plinfy=plinfy
+	Featol=featol
+	LP.Name=Name
+	LP.ObjRow=-1
+	colIndex = make(map[string]int)
+	rowIndex = make(map[string]int)
+}
+//=============================================================================================
+// Adds a column with bounds Lo and Up. Infinite bounds are given as -Plinfy and Plinfy.
+// A semi-continuous or semi-integer column may be 0 or between its bounds.
+// Status: 0(success), 1(name missing or already used)
+func AddColumn(Name string, Type COLTYPE, Lo float64, Up float64) (icol int, Status int) {
+	if Name == "" {
+		fmt.Println("Error: AddColumn needs a column name.")
+		return -1, 1
+	}
+	if _, Found := ColumnNumber(Name); Found {
+		fmt.Println("Error: there is already a column named",Name)
+		return -1, 1
+	}
+	var Col COL
+	Col.Name = Name
+	Col.Type = Type
+	Col.BndLo = Lo
+	Col.BndUp = Up
+	Col.ScaleFactor = 1.0
+	LP.Cols = append(LP.Cols, Col)
+	LP.NumCols++
+	icol = LP.NumCols-1
+	colIndex[Name] = icol
+	exactNewCol()
+	if Lo <= -Plinfy {exactBound(icol,"MI","")} else {exactBound(icol,"LO",exactText(Lo))}
+	if Up >= Plinfy {exactBound(icol,"PL","")} else {exactBound(icol,"UP",exactText(Up))}
+	return icol, 0
+}
+//=============================================================================================
+// Adds a row. RHS is applied as in the RHS section of an MPS file: the lower bound of a G row,
+// the upper bound of an L row, both bounds of an E row. A nonzero Range turns the row into a
+// range row as in the RANGES section; it is ignored for N rows.
+// Status: 0(success), 1(name missing or already used)
+func AddRow(Name string, Type ROWTYPE, RHS float64, Range float64) (irow int, Status int) {
+	if Name == "" {
+		fmt.Println("Error: AddRow needs a row name.")
+		return -1, 1
+	}
+	if _, Found := RowNumber(Name); Found {
+		fmt.Println("Error: there is already a row named",Name)
+		return -1, 1
+	}
+	var Row ROW
+	Row.Name = Name
+	Row.Type = Type
+	Row.ScaleFactor = 1.0
+	switch Type {
+	case RowG:
+		Row.RHSlo, Row.RHSup = RHS, Plinfy
+	case RowL:
+		Row.RHSlo, Row.RHSup = -Plinfy, RHS
+	case RowE, RowN:
+		Row.RHSlo, Row.RHSup = RHS, RHS
+	}
+	LP.Rows = append(LP.Rows, Row)
+	LP.NumRows++
+	irow = LP.NumRows-1
+	rowIndex[Name] = irow
+	exactNewRow()
+	exactRHS(irow, exactText(RHS))
+	if Range == 0.0 || Type == RowN {return irow, 0}
+
+	exactRange(irow, exactText(Range))
+	Width := Range
+	if Width < 0.0 {Width = -Width}
+	switch Type {
+	case RowG:
+		LP.Rows[irow].RHSup = LP.Rows[irow].RHSlo + Width
+	case RowL:
+		LP.Rows[irow].RHSlo = LP.Rows[irow].RHSup - Width
+	case RowE:
+		if Range > 0.0 {
+			LP.Rows[irow].RHSup = LP.Rows[irow].RHSlo + Width
+		} else {
+			LP.Rows[irow].RHSlo = LP.Rows[irow].RHSup - Width
+		}
+	}
+	LP.Rows[irow].Type = RowR
+	return irow, 0
+}
+//=============================================================================================
+// Sets the coefficient of column icol in row irow, adding the element if there isn't one.
+// On a finished model use SetRowCoefficients, which also brings the derived data up to date.
+// Status: 0(success), 1(bad row or column number)
+func SetCoefficient(irow int, icol int, Value float64) (Status int) {
+	if irow < 0 || irow >= LP.NumRows || icol < 0 || icol >= LP.NumCols {
+		fmt.Println("Error: SetCoefficient given row",irow,"and column",icol,"but the model has",LP.NumRows,"rows and",LP.NumCols,"columns.")
+		return 1
+	}
+	if iel := findElement(irow, icol); iel >= 0 {
+		Element[iel].Value = Value
+		if KeepExact {ExactEl[iel] = ParseExact(exactText(Value))}
+		return 0
+	}
+	Element = append(Element, ELEMENT{Row: irow, Col: icol, Value: Value})
+	NumElements++
+	exactElement(exactText(Value))
+	LP.Rows[irow].ElList = append(LP.Rows[irow].ElList, NumElements-1)
+	LP.Rows[irow].NumEl++
+	LP.Cols[icol].ElList = append(LP.Cols[icol].ElList, NumElements-1)
+	LP.Cols[icol].NumEl++
+	return 0
+}
+//=============================================================================================
+// Sets the objective coefficients of columns Cols to Vals. The objective is the first N row,
+// which is added as OBJ if there isn't one yet. Other coefficients are left as they are.
+// Status: 0(success), 1(bad column number or mismatched lists)
+func SetObjective(Cols []int, Vals []float64) (Status int) {
+	if len(Cols) != len(Vals) {
+		fmt.Println("Error: SetObjective given",len(Cols),"columns but",len(Vals),"values.")
+		return 1
+	}
+	if LP.ObjRow < 0 {
+		LP.ObjRow = firstNRow()
+	}
+	if LP.ObjRow < 0 {
+		if LP.ObjRow, Status = AddRow("OBJ", RowN, 0.0, 0.0); Status > 0 {return 1}
+	}
+	for k, j := range Cols {
+		if SetCoefficient(LP.ObjRow, j, Vals[k]) > 0 {return 1}
+	}
+	return 0
+}
+//=============================================================================================
+// Finishes the model after it has been built or changed with the routines above, so that the
+// solver can use it.
+// Status: 0(success), 1(no columns)
+func EndModel() (Status int) {
+	if LP.NumCols == 0 {
+		fmt.Println("Error: the model has no columns.")
+		return 1
+	}
+	if LP.ObjRow < 0 {LP.ObjRow = firstNRow()}
+	finishModel()
+	GetStatistics()
+	return 0
+}
+//=============================================================================================
+// Number of the column with the given name
+func ColumnNumber(Name string) (icol int, Found bool) {
+	if len(colIndex) != LP.NumCols {
+		colIndex = make(map[string]int, LP.NumCols)
+		for j:=0; j<LP.NumCols; j++ {colIndex[LP.Cols[j].Name] = j}
+	}
+	icol, Found = colIndex[Name]
+	if !Found {return -1, false}
+	return icol, true
+}
+//=============================================================================================
+// Number of the row with the given name
+func RowNumber(Name string) (irow int, Found bool) {
+	if len(rowIndex) != LP.NumRows {
+		rowIndex = make(map[string]int, LP.NumRows)
+		for i:=0; i<LP.NumRows; i++ {rowIndex[LP.Rows[i].Name] = i}
+	}
+	irow, Found = rowIndex[Name]
+	if !Found {return -1, false}
+	return irow, true
+}
+//=============================================================================================
+// The element for row irow and column icol, or -1. Searches the shorter of the two lists.
+func findElement(irow int, icol int) int {
+	if LP.Rows[irow].NumEl <= LP.Cols[icol].NumEl {
+		for _, iel := range LP.Rows[irow].ElList {
+			if Element[iel].Col == icol {return iel}
+		}
+		return -1
+	}
+	for _, iel := range LP.Cols[icol].ElList {
+		if Element[iel].Row == irow {return iel}
+	}
+	return -1
+}
+//=============================================================================================
+func firstNRow() int {
+	for i:=0; i<LP.NumRows; i++ {
+		if LP.Rows[i].Type == RowN {return i}
+	}
+	return -1
+}
+//=============================================================================================
+// The shortest decimal that reads back as Value, as it would be written to an MPS file
+func exactText(Value float64) string {
+	return strconv.FormatFloat(Value, 'g', -1, 64)
+}
@@ -0,0 +1,247 @@
+package lp
+
+import (
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+//=======================================================================================
+// Reads an MPS model given as text, with the exact data kept
+func readMPSText(tb testing.TB, Text string) {
+	tb.Helper()
+	Save := KeepExact
+	KeepExact = true
+	tb.Cleanup(func() { KeepExact = Save })
+
+	FileName := filepath.Join(tb.TempDir(), "model.mps")
+	if err := os.WriteFile(FileName, []byte(Text), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	if ReadMPSFile(FileName, 1.0e10, 1.0e-6) > 0 {
+		tb.Fatal("ReadMPSFile failed")
+	}
+}
+
+// The model that is read in, by names rather than by element and row order
+type modelSnapshot struct {
+	Name   string
+	ObjRow string
+	Rows   []rowSnapshot
+	Cols   []colSnapshot
+}
+
+type rowSnapshot struct {
+	Name       string
+	Type       ROWTYPE
+	RHSlo      float64
+	RHSup      float64
+	Coefs      map[string]float64 // by column name, from the CSR arrays
+	ExactLo    string
+	ExactUp    string
+	ExactCoefs map[string]string // by column name
+}
+
+type colSnapshot struct {
+	Name    string
+	Type    COLTYPE
+	BndLo   float64
+	BndUp   float64
+	NumEl   int
+	ExactLo string
+	ExactUp string
+}
+
+//=======================================================================================
+func ratText(r *big.Rat) string {
+	if r == nil {
+		return "inf"
+	}
+	return r.RatString()
+}
+
+//=======================================================================================
+func takeSnapshot() (Snap modelSnapshot) {
+	Snap.Name = LP.Name
+	if LP.ObjRow >= 0 {
+		Snap.ObjRow = LP.Rows[LP.ObjRow].Name
+	}
+	for i := 0; i < LP.NumRows; i++ {
+		Row := &LP.Rows[i]
+		RowSnap := rowSnapshot{Name: Row.Name, Type: Row.Type, RHSlo: Row.RHSlo, RHSup: Row.RHSup,
+			Coefs: make(map[string]float64), ExactCoefs: make(map[string]string)}
+		for k := LP.RowStart[i]; k < LP.RowStart[i+1]; k++ {
+			RowSnap.Coefs[LP.Cols[LP.ColIdx[k]].Name] = LP.RowVal[k]
+		}
+		for _, iel := range Row.ElList {
+			RowSnap.ExactCoefs[LP.Cols[Element[iel].Col].Name] = ratText(ExactEl[iel])
+		}
+		RowSnap.ExactLo, RowSnap.ExactUp = ratText(ExactRows[i].Lo), ratText(ExactRows[i].Up)
+		Snap.Rows = append(Snap.Rows, RowSnap)
+	}
+	for j := 0; j < LP.NumCols; j++ {
+		Col := &LP.Cols[j]
+		Snap.Cols = append(Snap.Cols, colSnapshot{Name: Col.Name, Type: Col.Type, BndLo: Col.BndLo, BndUp: Col.BndUp,
+			NumEl: Col.NumEl, ExactLo: ratText(ExactCols[j].Lo), ExactUp: ratText(ExactCols[j].Up)})
+	}
+	return Snap
+}
+
+//=======================================================================================
+// Coefficients given row by row, as a caller of the builder would
+type builderRow struct {
+	Name  string
+	Type  ROWTYPE
+	RHS   float64
+	Range float64
+	Coefs map[string]float64
+}
+
+type builderCol struct {
+	Name string
+	Type COLTYPE
+	Lo   float64
+	Up   float64
+}
+
+//=======================================================================================
+// A model built in memory is the same as the equivalent MPS file read in: bounds, ranges,
+// column types, elements, derived data and exact data
+func TestBuilderMatchesMPS(t *testing.T) {
+	tests := []struct {
+		Name string
+		MPS  string
+		Cols []builderCol
+		Rows []builderRow
+	}{
+		{
+			Name: "bounds and row types",
+			MPS: `NAME          LPTEST
+ROWS
+ N  COST
+ G  R1
+ L  R2
+ E  R3
+COLUMNS
+    X         COST      1            R1        1
+    X         R2        2.5
+    Y         COST      -2           R1        1
+    Y         R3        1
+    Z         R2        0.1          R3        -1
+    W         R1        3
+RHS
+    RHSV      R1        1            R2        10
+    RHSV      R3        0.3
+BOUNDS
+ UP BND       X         4
+ MI BND       Y
+ FX BND       Z         0.7
+ FR BND       W
+ENDATA
+`,
+			Cols: []builderCol{{"X", ColR, 0.0, 4.0}, {"Y", ColR, -1.0e10, 1.0e10}, {"Z", ColR, 0.7, 0.7}, {"W", ColR, -1.0e10, 1.0e10}},
+			Rows: []builderRow{
+				{"COST", RowN, 0.0, 0.0, map[string]float64{"X": 1.0, "Y": -2.0}},
+				{"R1", RowG, 1.0, 0.0, map[string]float64{"X": 1.0, "Y": 1.0, "W": 3.0}},
+				{"R2", RowL, 10.0, 0.0, map[string]float64{"X": 2.5, "Z": 0.1}},
+				{"R3", RowE, 0.3, 0.0, map[string]float64{"Y": 1.0, "Z": -1.0}},
+			},
+		},
+		{
+			Name: "range row",
+			MPS: `NAME          RANGETEST
+ROWS
+ N  OBJ
+ E  R1
+ L  R2
+COLUMNS
+    X         OBJ       1            R1        1
+    X         R2        1
+    Y         R1        -1           R2        2
+RHS
+    RHSV      R1        2.5          R2        8
+RANGES
+    RNG       R1        -1.5
+ENDATA
+`,
+			Cols: []builderCol{{"X", ColR, 0.0, 1.0e10}, {"Y", ColR, 0.0, 1.0e10}},
+			Rows: []builderRow{
+				{"OBJ", RowN, 0.0, 0.0, map[string]float64{"X": 1.0}},
+				{"R1", RowE, 2.5, -1.5, map[string]float64{"X": 1.0, "Y": -1.0}},
+				{"R2", RowL, 8.0, 0.0, map[string]float64{"X": 1.0, "Y": 2.0}},
+			},
+		},
+		{
+			Name: "integer and semi-continuous columns",
+			MPS: `NAME          MIPTEST
+ROWS
+ N  COST
+ L  C1
+COLUMNS
+    MARKER                 'MARKER'                 'INTORG'
+    N         COST      -1           C1        1
+    B         COST      -2           C1        3
+    S         COST      1            C1        1
+    MARKER                 'MARKER'                 'INTEND'
+    C         COST      1            C1        0.5
+RHS
+    RHSV      C1        9.5
+BOUNDS
+ UP BND       N         6
+ BV BND       B
+ SC BND       S         4
+ SC BND       C         2.25
+ENDATA
+`,
+			Cols: []builderCol{{"N", ColI, 0.0, 6.0}, {"B", ColI, 0.0, 1.0}, {"S", ColSI, 0.0, 4.0}, {"C", ColSC, 0.0, 2.25}},
+			Rows: []builderRow{
+				{"COST", RowN, 0.0, 0.0, map[string]float64{"N": -1.0, "B": -2.0, "S": 1.0, "C": 1.0}},
+				{"C1", RowL, 9.5, 0.0, map[string]float64{"N": 1.0, "B": 3.0, "S": 1.0, "C": 0.5}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			readMPSText(t, tt.MPS)
+			Read := takeSnapshot()
+			ReadGrad := make([]float64, LP.NumRows)
+			for i := range ReadGrad {
+				ReadGrad[i] = LP.Rows[i].GradVecLenSq
+			}
+
+			NewModel(Read.Name, 1.0e10, 1.0e-6)
+			for _, Col := range tt.Cols {
+				if _, Status := AddColumn(Col.Name, Col.Type, Col.Lo, Col.Up); Status > 0 {
+					t.Fatalf("AddColumn %s failed", Col.Name)
+				}
+			}
+			for _, Row := range tt.Rows {
+				irow, Status := AddRow(Row.Name, Row.Type, Row.RHS, Row.Range)
+				if Status > 0 {
+					t.Fatalf("AddRow %s failed", Row.Name)
+				}
+				for Name, Value := range Row.Coefs {
+					icol, _ := ColumnNumber(Name)
+					SetCoefficient(irow, icol, Value)
+				}
+			}
+			if EndModel() > 0 {
+				t.Fatal("EndModel failed")
+			}
+			Built := takeSnapshot()
+
+			if !reflect.DeepEqual(Built, Read) {
+				t.Errorf("built model\n%+v\nread model\n%+v", Built, Read)
+			}
+			// The element order differs, so the sums of squares may differ in the last bit
+			for i := 0; i < LP.NumRows; i++ {
+				if math.Abs(LP.Rows[i].GradVecLenSq-ReadGrad[i]) > 1.0e-15*ReadGrad[i] {
+					t.Errorf("row %s: GradVecLenSq %v built, %v read", LP.Rows[i].Name, LP.Rows[i].GradVecLenSq, ReadGrad[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package lp
+
+// Exact rational copies of the model data, taken from the decimal strings in the MPS file
+// rather than from the float64 values. Kept only if KeepExact is set before ReadMPSFile,
+// since ordinary runs don't need them. They describe the model as read: ScaleRows and
+// ScaleColumns do not update them.
+
+import (
+	"math/big"
+	"strconv"
+)
+
+type EXACTBOUNDS struct {
+	Lo *big.Rat // nil: minus infinity
+	Up *big.Rat // nil: plus infinity
+}
+
+var KeepExact bool
+var ExactEl []*big.Rat        // Exact value of each element, parallel to Element
+var ExactRows []EXACTBOUNDS   // Exact RHS bounds of each row, set the same way as RHSlo and RHSup
+var ExactCols []EXACTBOUNDS   // Exact bounds of each column
+var NumInexact int            // Number of tokens that were not plain decimals, so only their float64 value is kept
+var ExactQ map[int][]*big.Rat // Exact values of the quadratic terms of the constraint rows, parallel to QTerms
+
+//=============================================================================================
+// Parses an MPS number exactly. Anything big.Rat cannot read is taken at its float64 value.
+func ParseExact(Text string) *big.Rat {
+	r, ok := new(big.Rat).SetString(Text)
+	if ok {return r}
+	NumInexact++
+	f,_ := strconv.ParseFloat(Text,64)
+	r = new(big.Rat)
+	if r.SetFloat64(f) == nil {r.SetInt64(0)}
+	return r
+}
+//=============================================================================================
+func exactReset() {
+	ExactEl=nil; ExactRows=nil; ExactCols=nil; ExactQ=nil; NumInexact=0
+}
+//=============================================================================================
+// New row: the reader leaves both RHS values at 0 until the RHS section says otherwise
+func exactNewRow() {
+	if !KeepExact {return}
+	ExactRows = append(ExactRows, EXACTBOUNDS{new(big.Rat), new(big.Rat)})
+}
+//=============================================================================================
+// New column: bounds [0, plus infinity]
+func exactNewCol() {
+	if !KeepExact {return}
+	ExactCols = append(ExactCols, EXACTBOUNDS{new(big.Rat), nil})
+}
+//=============================================================================================
+func exactElement(Text string) {
+	if !KeepExact {return}
+	ExactEl = append(ExactEl, ParseExact(Text))
+}
+//=============================================================================================
+// Mirrors the RHS section of ReadMPSFile
+func exactRHS(irow int, Text string) {
+	if !KeepExact {return}
+	r := ParseExact(Text)
+	switch LP.Rows[irow].Type {
+		case RowG:
+			ExactRows[irow] = EXACTBOUNDS{r, nil}
+		case RowL:
+			ExactRows[irow] = EXACTBOUNDS{nil, r}
+		case RowE,RowN:
+			ExactRows[irow] = EXACTBOUNDS{r, new(big.Rat).Set(r)}
+	}
+}
+//=============================================================================================
+// Mirrors the RANGES section of ReadMPSFile. Call before the row type is changed to RowR.
+func exactRange(irow int, Text string) {
+	if !KeepExact {return}
+	R := ParseExact(Text)
+	Sign := R.Sign()
+	R.Abs(R)
+	Bnds := &ExactRows[irow]
+	switch LP.Rows[irow].Type {
+	case RowG:
+		Bnds.Up = new(big.Rat).Add(Bnds.Lo, R)
+	case RowL:
+		Bnds.Lo = new(big.Rat).Sub(Bnds.Up, R)
+	case RowE:
+		if Sign > 0 {
+			Bnds.Up = new(big.Rat).Add(Bnds.Lo, R)
+		} else {
+			Bnds.Lo = new(big.Rat).Sub(Bnds.Up, R)
+		}
+	}
+}
+//=============================================================================================
+// Mirrors the BOUNDS section of ReadMPSFile
+func exactBound(icol int, Kind string, Text string) {
+	if !KeepExact {return}
+	var r *big.Rat
+	if Kind != "FR" && Kind != "PL" && Kind != "MI" && Kind != "BV" && Text != "" {r = ParseExact(Text)}
+	Bnds := &ExactCols[icol]
+	switch Kind {
+	case "LO":
+		Bnds.Lo = r
+	case "UP":
+		Bnds.Up = r
+	case "FX":
+		Bnds.Lo = r
+		Bnds.Up = new(big.Rat).Set(r)
+	case "FR":
+		Bnds.Lo = nil
+		Bnds.Up = nil
+	case "MI":
+		Bnds.Lo = nil
+	case "PL":
+		Bnds.Up = nil
+	case "BV":
+		Bnds.Lo = new(big.Rat)
+		Bnds.Up = big.NewRat(1,1)
+	case "LI":
+		Bnds.Lo = r
+		Bnds.Up = nil
+	case "UI":
+		Bnds.Lo = new(big.Rat)
+		Bnds.Up = r
+	case "SC":
+		Bnds.Up = r // nil (infinite) if the bound has no value
+	}
+}
+//=============================================================================================
+// A quadratic term of a constraint row, from the QCMATRIX section
+func exactQTerm(irow int, Text string) {
+	if !KeepExact {return}
+	if ExactQ == nil {ExactQ = make(map[int][]*big.Rat)}
+	ExactQ[irow] = append(ExactQ[irow], ParseExact(Text))
+}
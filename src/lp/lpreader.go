@@ -16,6 +16,7 @@ import (
 	"strings"
 	"strconv"
 	"math"
+	"sort"
 )
 
 type ELEMENT struct {
@@ -24,9 +25,30 @@ type ELEMENT struct {
 	Value float64
 }
 
+// Row and column kinds. These are parsed once by the reader so that the inner
+// evaluation loops switch on small integers instead of comparing strings.
+type ROWTYPE int
+
+const (
+	RowN ROWTYPE = iota // Nonbinding row (e.g. the objective function)
+	RowG                // Greater than or equal to row
+	RowL                // Less than or equal to row
+	RowE                // Equality row
+	RowR                // Range row
+)
+
+type COLTYPE int
+
+const (
+	ColR COLTYPE = iota // Real-valued column
+	ColI                // Integer column
+	ColSC               // Semi-continuous column: 0 or a value between its bounds
+	ColSI               // Semi-integer column: 0 or an integer between its bounds
+)
+
 type COL struct {
 	Name   string
-	Type   string
+	Type   COLTYPE
 	BndUp  float64
 	BndLo  float64
 	NumEl  int
@@ -36,13 +58,31 @@ type COL struct {
 
 type ROW struct {
 	Name   string
-	Type   string
+	Type   ROWTYPE
 	RHSlo float64
 	RHSup float64
 	NumEl  int
 	GradVecLenSq float64 // Length of gradient vector squared
+	InvGradVecLenSq float64 // 1/GradVecLenSq, or 0 for an empty row
+	InvGradVecLen float64 // 1/sqrt(GradVecLenSq), or 0 for an empty row
 	ElList []int
 	ScaleFactor float64
+	QTerms []QTERM // Quadratic part of the body, if any: the sum of Value*x[Col1]*x[Col2] over the terms
+	QCols  []int   // For a row with quadratic terms, every column in its body, in increasing order
+}
+
+type QTERM struct { // One term of the quadratic part of a row body
+	Col1  int
+	Col2  int
+	Value float64
+}
+
+type SOSSET struct { // Special ordered set
+	Name     string
+	Type     int       // 1: at most one member nonzero, 2: at most two, adjacent in weight order
+	Priority int
+	Cols     []int     // Member columns, in order of increasing weight after reading
+	Weights  []float64 // Weight of each member
 }
 
 type LPOBJ struct { //Linear programming object
@@ -52,6 +92,14 @@ type LPOBJ struct { //Linear programming object
 	Rows    []ROW  //List of rows
 	Cols    []COL  //List of columns
 	ObjRow  int    //Row number of objective function
+	SOS     []SOSSET //Special ordered sets from the SOS section, if any
+	// Compressed sparse row (CSR) and column (CSC) copies of the constraint matrix, built by BuildCompressed
+	RowStart []int     //Row i occupies positions RowStart[i] to RowStart[i+1]-1 of ColIdx and RowVal
+	ColIdx   []int     //Column index of each nonzero, stored row by row
+	RowVal   []float64 //Value of each nonzero, stored row by row
+	ColStart []int     //Column j occupies positions ColStart[j] to ColStart[j+1]-1 of RowIdx and ColVal
+	RowIdx   []int     //Row index of each nonzero, stored column by column
+	ColVal   []float64 //Value of each nonzero, stored column by column
 }
 // Global Variables
 
@@ -61,6 +109,8 @@ var EmptyLP LPOBJ
 var Element []ELEMENT
 var NumElements,NumRows,NumCols int
 var NumGRows,NumLRows,NumERows,NumNRows,NumRRows,NumICols,NumRCols,MaxElsInRow,MaxElsInCol int
+var NumSCols int // semi-continuous and semi-integer columns, also counted in NumRCols or NumICols
+var NumQRows int // binding rows with quadratic terms, also counted by type
 var TotCons int // total number of binding constraints (equalities count as one, ranges count as two)
 var TotBnds int // total number of binding bounds (fixed variables count as one)
 var	AvgElsPerRow,AvgElsPerCol float64
@@ -86,6 +136,10 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 	var NumBoundSets int = 0
 	var BoundSetName string
 	var realhold, realhold1 float64
+	var QFull bool // QMATRIX rather than QUADOBJ
+	var QRow int // row of the current QCMATRIX section, -1 if unknown
+	var ObjQTerms []QTERM // the quadratic objective, attached to the objective row once it is known
+	var ColIndex map[string]int // column numbers by name, built for the quadratic sections, which can be long
 	
 	Status = 0
 	
@@ -166,6 +220,29 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 			ReadState=5
 			continue
 
+		case "SOS":
+			ReadState=7
+			continue
+
+		case "QUADOBJ", "QMATRIX": // quadratic objective: the upper triangle, or the whole symmetric matrix
+			ReadState=8
+			QFull = strings.ToUpper(Token[0]) == "QMATRIX"
+			continue
+
+		case "QCMATRIX": // quadratic part of a constraint, given as the whole symmetric matrix
+			ReadState=9
+			QRow = -1
+			if NumTokens > 1 {
+				for i:=0; i<LP.NumRows; i++ {
+					if Token[1]==LP.Rows[i].Name {
+						QRow=i
+						break
+					}
+				}
+			}
+			if QRow < 0 {fmt.Println("Warning: no match for QCMATRIX row name on MPS line ",MPSLineNum,". Its terms are skipped.")}
+			continue
+
 		case "ENDATA":
 			fmt.Println("ENDATA reached at line",MPSLineNum)
 			ReadState=6
@@ -173,9 +250,11 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 		
 		if ReadState==6 {break} // Do not read any more lines
 		
-		if NumTokens > 1 && Token[1]=="'MARKER'" {
-			if Token[2]=="'INTORG'" {MarkAsInteger=true}
-			if Token[2]=="'INTEND'" {MarkAsInteger=false}
+		if NumTokens > 2 && strings.Trim(Token[1],"'")=="MARKER" {
+			// Integer markers are not column data: don't read them as a column named after the marker
+			if strings.Trim(Token[2],"'")=="INTORG" {MarkAsInteger=true}
+			if strings.Trim(Token[2],"'")=="INTEND" {MarkAsInteger=false}
+			continue
 		}
 		
 		//---------------------------------------------------
@@ -183,10 +262,19 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 		switch ReadState {
 		
 		case 1:  // Reading row names
+			if NumTokens < 2 {
+				fmt.Println("Error: row type or name missing on MPS file line",MPSLineNum,". Read aborted")
+				return 1
+			}
+			tempRow.Type, Found = ParseRowType(Token[0])
+			if !Found {
+				fmt.Println("Error: unknown row type",Token[0],"on MPS file line",MPSLineNum,". Read aborted")
+				return 1
+			}
 			LP.NumRows++
-			tempRow.Type=Token[0]
 			tempRow.Name=Token[1]
 			LP.Rows=append(LP.Rows,tempRow)
+			exactNewRow()
 			//test
 			//fmt.Println("Row: ",LP.NumRows," Row type: ", tempRow.Type, " Name: ", tempRow.Name)
 			
@@ -196,12 +284,13 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 				// found a new column
 				LP.NumCols++
 				tempCol.NumEl = 0
-				tempCol.Type="R"
+				tempCol.Type=ColR
 				tempCol.BndUp = plinfy // Initialize upper bound to plus infinity
 				tempCol.BndLo = 0.0
-				if MarkAsInteger {tempCol.Type="I"}
+				if MarkAsInteger {tempCol.Type=ColI}
 				//TODO: deal with other types, like binary
 				LP.Cols=append(LP.Cols,tempCol)
+				exactNewCol()
 			}
 			LastColName = tempCol.Name
 			// add first element given in the line
@@ -215,6 +304,7 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 					tempElement.Row=i
 					tempElement.Value,_ = strconv.ParseFloat(Token[2],64)
 					Element=append(Element,tempElement)
+					exactElement(Token[2])
 					LP.Rows[i].ElList=append(LP.Rows[i].ElList,NumElements-1)
 					LP.Cols[LP.NumCols-1].ElList=append(LP.Cols[LP.NumCols-1].ElList,NumElements-1)
 					LP.Cols[LP.NumCols-1].NumEl++
@@ -236,6 +326,7 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 						tempElement.Row=i
 						tempElement.Value,_ = strconv.ParseFloat(Token[4],64)
 						Element=append(Element,tempElement)
+						exactElement(Token[4])
 						LP.Rows[i].ElList=append(LP.Rows[i].ElList,NumElements-1)
 						LP.Cols[LP.NumCols-1].ElList=append(LP.Cols[LP.NumCols-1].ElList,NumElements-1)
 						LP.Cols[LP.NumCols-1].NumEl++
@@ -268,14 +359,15 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 				return 1
 			}
 			realhold,_=strconv.ParseFloat(Token[2],64)
+			exactRHS(ihold,Token[2])
 			switch LP.Rows[ihold].Type {
-				case "G":
+				case RowG:
 					LP.Rows[ihold].RHSlo=realhold
 					LP.Rows[ihold].RHSup=plinfy
-				case "L":
+				case RowL:
 					LP.Rows[ihold].RHSlo=-plinfy
 					LP.Rows[ihold].RHSup=realhold
-				case "E","N":
+				case RowE,RowN:
 					LP.Rows[ihold].RHSlo=realhold
 					LP.Rows[ihold].RHSup=realhold
 			}
@@ -295,14 +387,15 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 					return 1
 				}
 				realhold,_=strconv.ParseFloat(Token[4],64)
+				exactRHS(ihold,Token[4])
 				switch LP.Rows[ihold].Type {
-					case "G":
+					case RowG:
 						LP.Rows[ihold].RHSlo=realhold
 						LP.Rows[ihold].RHSup=plinfy
-					case "L":
+					case RowL:
 						LP.Rows[ihold].RHSlo=-plinfy
 						LP.Rows[ihold].RHSup=realhold
-					case "E","N":
+					case RowE,RowN:
 						LP.Rows[ihold].RHSlo=realhold
 						LP.Rows[ihold].RHSup=realhold
 				}								
@@ -313,7 +406,7 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 				// First bound set found
 				NumBoundSets++
 				BoundSetName = Token[1]
-				if Token[0] == "FR" || Token[0] == "PL" || Token[0] == "MI" {
+				if Token[0] == "FR" || Token[0] == "PL" || Token[0] == "MI" || Token[0] == "BV" || Token[0] == "SC" {
 					if NumTokens < 3 {
 						fmt.Println("WARNING: too few tokens on MPS file line",MPSLineNum,". Bound set name likely missing.")
 					} 
@@ -321,7 +414,7 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 					fmt.Println("WARNING: too few tokens on MPS file line",MPSLineNum,". Bound set name likely missing.")
 				}	
 			}
-			if Token[0] == "FR" || Token[0] == "PL" || Token[0] == "MI" {
+			if Token[0] == "FR" || Token[0] == "PL" || Token[0] == "MI" || Token[0] == "BV" || Token[0] == "SC" {
 				if NumTokens < 3 {
 					fmt.Println("WARNING: too few tokens on MPS file line",MPSLineNum,". Bound set name may be missing.")
 				} 
@@ -343,7 +436,17 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 				fmt.Println("Warning: no match for column name on MPS line ",MPSLineNum,". Continuing...")
 				continue
 			}
-			if Token[0] != "FR" && Token[0] != "PL" && Token[0] != "MI" {realhold,_ = strconv.ParseFloat(Token[3],64)}
+			realhold = plinfy
+			if Token[0] == "BV" {
+				exactBound(ihold,Token[0],"") // the value of a BV bound is optional and ignored
+			} else if Token[0] == "SC" && NumTokens < 4 {
+				exactBound(ihold,Token[0],"") // an SC bound without a value leaves the upper bound infinite
+			} else if Token[0] != "FR" && Token[0] != "PL" && Token[0] != "MI" {
+				realhold,_ = strconv.ParseFloat(Token[3],64)
+				exactBound(ihold,Token[0],Token[3])
+			} else {
+				exactBound(ihold,Token[0],"")
+			}
 			switch Token[0] {
 			case "LO":
 				LP.Cols[ihold].BndLo = realhold
@@ -360,20 +463,23 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 			case "PL":
 				LP.Cols[ihold].BndUp = plinfy
 			case "BV":  // Binary variable
-				LP.Cols[ihold].Type = "I"
+				LP.Cols[ihold].Type = ColI
 				LP.Cols[ihold].BndLo = 0.0
 				LP.Cols[ihold].BndUp = 1.0
 			case "LI": // Lower bounded integer variable
-				LP.Cols[ihold].Type = "I"
+				LP.Cols[ihold].Type = ColI
 				LP.Cols[ihold].BndLo = realhold
 				LP.Cols[ihold].BndUp = plinfy
 			case "UI": // Upper bounded integer variable
-				LP.Cols[ihold].Type = "I"
+				LP.Cols[ihold].Type = ColI
 				LP.Cols[ihold].BndLo = 0.0
 				LP.Cols[ihold].BndUp = realhold
-			case "SC": // Semi-continuous variable
-				fmt.Println("Warning: only the continuous part of a semi-continuous variable is handled. Lower bound = 1.0.")
-				LP.Cols[ihold].BndLo = 1.0
+			case "SC": // Semi-continuous (semi-integer if integer) variable: 0 or between its bounds
+				if LP.Cols[ihold].Type == ColI {
+					LP.Cols[ihold].Type = ColSI
+				} else {
+					LP.Cols[ihold].Type = ColSC
+				}
 				LP.Cols[ihold].BndUp = realhold
 			default:
 				fmt.Println("Error: no match for bound type on MPS file line ",MPSLineNum,". Continuing...")
@@ -401,29 +507,127 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 				}
 				realhold,_ = strconv.ParseFloat(Token[2],64)
 				realhold1 = realhold	// The sign is needed for E type ranges
+				exactRange(ihold,Token[2])
 				if realhold < 0.0 {realhold = -realhold} // Absolute value is needed in some cases
 				switch LP.Rows[ihold].Type {
-				case "G":
+				case RowG:
 					LP.Rows[ihold].RHSup = LP.Rows[ihold].RHSlo + realhold
-					LP.Rows[ihold].Type="R"
+					LP.Rows[ihold].Type=RowR
 					NumGRows = NumGRows - 1
 					NumRRows = NumRRows + 1
-				case "L":
+				case RowL:
 					LP.Rows[ihold].RHSlo =  LP.Rows[ihold].RHSup - realhold
-					LP.Rows[ihold].Type="R"
+					LP.Rows[ihold].Type=RowR
 					NumLRows = NumLRows - 1
 					NumRRows = NumRRows + 1
-				case "E":
+				case RowE:
 					if realhold1 > 0.0 {
 						LP.Rows[ihold].RHSup = LP.Rows[ihold].RHSlo + realhold
 					} else {
 						LP.Rows[ihold].RHSlo = LP.Rows[ihold].RHSup - realhold
 					}
-					LP.Rows[ihold].Type="R"
+					LP.Rows[ihold].Type=RowR
 					NumERows = NumERows - 1
 					NumRRows = NumRRows + 1
 				} // end of switch on row type
 			} // end of switch on case 5
+			case 7: { // reading SOS. Both the CPLEX and the Gurobi layouts are read.
+				// A set starts with a header: S1 or S2, the keyword SOS (SOS1 or SOS2 for Gurobi), then
+				// optionally the set name and priority, either as two fields or as name:priority.
+				// Each member follows on its own line as "column weight" or "column:weight",
+				// possibly preceded by the set name.
+				Upper := strings.ToUpper(Token[0])
+				if Upper == "S1" || Upper == "S2" {
+					if NumTokens == 1 || strings.ToUpper(Token[1]) == "SOS" || strings.ToUpper(Token[1]) == "SOS1" || strings.ToUpper(Token[1]) == "SOS2" {
+						var Set SOSSET
+						Set.Type = 1
+						if Upper == "S2" {Set.Type = 2}
+						Set.Name = fmt.Sprintf("SOS%d", len(LP.SOS)+1)
+						Fields := Token[1:]
+						if len(Fields) > 0 {Fields = Fields[1:]} // the SOS keyword
+						if len(Fields) == 1 && strings.Contains(Fields[0], ":") {
+							Colon := strings.LastIndex(Fields[0], ":")
+							Fields = []string{Fields[0][:Colon], Fields[0][Colon+1:]}
+						}
+						for _, Field := range Fields {
+							if Priority, err := strconv.Atoi(Field); err == nil {
+								Set.Priority = Priority
+							} else {
+								Set.Name = Field
+							}
+						}
+						LP.SOS = append(LP.SOS, Set)
+						continue
+					}
+				}
+				if len(LP.SOS) == 0 {
+					fmt.Println("Warning: SOS member before any set header on MPS line ",MPSLineNum,". Continuing...")
+					continue
+				}
+				Set := &LP.SOS[len(LP.SOS)-1]
+				Fields := Token
+				if NumTokens > 1 && Token[0] == Set.Name && (NumTokens == 3 || strings.Contains(Token[1], ":")) {
+					Fields = Token[1:] // the member line repeats the set name
+				}
+				if len(Fields) == 1 && strings.Contains(Fields[0], ":") {
+					Colon := strings.LastIndex(Fields[0], ":")
+					Fields = []string{Fields[0][:Colon], Fields[0][Colon+1:]}
+				}
+				Weight := float64(len(Set.Cols) + 1) // members without a weight are taken in the order given
+				if len(Fields) > 1 {
+					realhold, err = strconv.ParseFloat(Fields[1], 64)
+					if err != nil {
+						fmt.Println("Warning: bad SOS weight on MPS line ",MPSLineNum,". Continuing...")
+						continue
+					}
+					Weight = realhold
+				}
+				Found=false
+				for i:=0;i<LP.NumCols;i++ {
+					if Fields[0]==LP.Cols[i].Name {
+						ihold=i
+						Found=true
+						break
+					}
+				}
+				if !Found {
+					fmt.Println("Warning: no match for column name on MPS line ",MPSLineNum,". Continuing...")
+					continue
+				}
+				Set.Cols = append(Set.Cols, ihold)
+				Set.Weights = append(Set.Weights, Weight)
+			} // end of case 7
+			case 8, 9: { // reading QUADOBJ/QMATRIX or QCMATRIX: column column value
+				// The objective is 0.5 x'Qx: QUADOBJ lists each off-diagonal pair once, QMATRIX twice.
+				// A constraint's quadratic part is x'Qx, with each off-diagonal pair listed twice.
+				if ReadState == 9 && QRow < 0 {continue}
+				if NumTokens < 3 {
+					fmt.Println("Warning: too few tokens on MPS file line",MPSLineNum,". Continuing...")
+					continue
+				}
+				if ColIndex == nil {
+					ColIndex = make(map[string]int, LP.NumCols)
+					for i:=0; i<LP.NumCols; i++ {ColIndex[LP.Cols[i].Name] = i}
+				}
+				Col1, Found1 := ColIndex[Token[0]]
+				Col2, Found2 := ColIndex[Token[1]]
+				if !Found1 || !Found2 {
+					fmt.Println("Warning: no match for column name on MPS line ",MPSLineNum,". Continuing...")
+					continue
+				}
+				realhold, err = strconv.ParseFloat(Token[2], 64)
+				if err != nil {
+					fmt.Println("Warning: bad quadratic coefficient on MPS line ",MPSLineNum,". Continuing...")
+					continue
+				}
+				if ReadState == 9 {
+					LP.Rows[QRow].QTerms = append(LP.Rows[QRow].QTerms, QTERM{Col1, Col2, realhold})
+					exactQTerm(QRow, Token[2])
+					continue
+				}
+				if QFull || Col1 == Col2 {realhold = 0.5*realhold}
+				ObjQTerms = append(ObjQTerms, QTERM{Col1, Col2, realhold})
+			} // end of case 8, 9
 		} // end of switch on ReadState ------------------------------------------
 	} // end of main line reading for --------------------------------------------
 
@@ -432,49 +636,81 @@ func ReadMPSFile(MPSFileLocation string, plinfy float64, featol float64) (Status
 	// We take the first nonbinding row as the objective function
 	ihold = -1 // initial row of objective function
 	for i:=0; i<LP.NumRows; i++ {
-		if LP.Rows[i].Type=="N" {
+		if LP.Rows[i].Type==RowN {
 			ihold=i
 			break
 		}
 	}
 	if ihold<0 {fmt.Println("Warning: no objective function in model!")}
 	LP.ObjRow=ihold
+	if ihold>=0 {
+		LP.Rows[ihold].QTerms = append(LP.Rows[ihold].QTerms, ObjQTerms...)
+	} else if len(ObjQTerms) > 0 {
+		fmt.Println("Warning: quadratic objective terms but no objective row. They are skipped.")
+	}
 	if ihold>=0 {
 		fmt.Println("Objective function:",LP.Rows[ihold].Name)
 		if LP.Rows[ihold].RHSlo != 0.0 || LP.Rows[ihold].RHSup != 0.0 {fmt.Println("Warning: objective function includes constant term.")}
 	}
 	
+	finishModel()
+
+	fmt.Println("MPS file reading complete.")
+	GetStatistics()
+	return 0
+} // End of ReadMPSFile function
+//=========================================================================================================
+// The checks and settings after a model is read or built, before GetStatistics: empty rows
+// and columns, the initial scale factors and the order of the special ordered sets.
+func finishModel() {
 	// Look for empty rows and columns. Also fill in the initial scale factors
 	for i:=0; i<LP.NumRows; i++ {
 		LP.Rows[i].ScaleFactor = 1.0
-		if LP.Rows[i].NumEl==0 {
+		if LP.Rows[i].NumEl==0 && len(LP.Rows[i].QTerms)==0 {
 			fmt.Println("Warning: row ",i," (",LP.Rows[i].Name,") has no elements. Converted to nonbinding type.")
-			LP.Rows[i].Type="N"
+			LP.Rows[i].Type=RowN
+		}
+	}
+	InQuadratic := make([]bool, LP.NumCols)
+	for i:=0; i<LP.NumRows; i++ {
+		for _, Term := range LP.Rows[i].QTerms {
+			InQuadratic[Term.Col1] = true
+			InQuadratic[Term.Col2] = true
 		}
 	}
 	for i:=0; i<LP.NumCols; i++ {
 		LP.Cols[i].ScaleFactor = 1.0
-		if LP.Cols[i].NumEl == 0 {
-			fmt.Println("Error in MPS file: column ",i," (",LP.Cols[i].Name,") has no elements. Aborting.")
+		if LP.Cols[i].NumEl == 0 && !InQuadratic[i] {
+			fmt.Println("Error in model: column ",i," (",LP.Cols[i].Name,") has no elements.")
 		}
 
 	}
 
-	//test
-	//fmt.Println("LP: ",LP)
-	//fmt.Println("Elements: ", Element)
-
-	fmt.Println("MPS file reading complete.")
-	GetStatistics()
-	return 0
-} // End of ReadMPSFile function
+	// Put the members of each special ordered set in weight order: SOS2 adjacency depends on it
+	for iset := range LP.SOS {
+		Set := &LP.SOS[iset]
+		Order := make([]int, len(Set.Cols))
+		for k := range Order {Order[k] = k}
+		sort.SliceStable(Order, func(a, b int) bool {return Set.Weights[Order[a]] < Set.Weights[Order[b]]})
+		Cols := make([]int, len(Order))
+		Weights := make([]float64, len(Order))
+		for k, ipos := range Order {
+			Cols[k] = Set.Cols[ipos]
+			Weights[k] = Set.Weights[ipos]
+			if k > 0 && Weights[k] == Weights[k-1] {
+				fmt.Println("Warning: SOS set",Set.Name,"has two members with weight",Weights[k],". Their order is as read.")
+			}
+		}
+		Set.Cols, Set.Weights = Cols, Weights
+		if len(Set.Cols) == 0 {fmt.Println("Warning: SOS set",Set.Name,"has no members.")}
+	}
+}
 
 //=========================================================================================================
 func ConBodyValue(FuncNum int, Point []float64) (BodyValue float64, Status int) {
 	// Calculates the LHS value of the given function
-	// Status values. 0:normal, 1:nonbinding, 2:problem.
+	// Status values. 0:normal, 1:nonbinding, 2:problem (e.g. NaN or infinite value).
 	
-	var icol, ielem int
 	var realhold float64
 	
 	if FuncNum < 0 || FuncNum > LP.NumRows-1 {
@@ -483,44 +719,39 @@ func ConBodyValue(FuncNum int, Point []float64) (BodyValue float64, Status int)
 		return 0.0, 2
 	}
 	
-	realhold = 0.0
-	for i:=0; i<LP.Rows[FuncNum].NumEl; i++ {
-		ielem=LP.Rows[FuncNum].ElList[i]
-		icol=Element[ielem].Col
-		realhold = realhold + Element[ielem].Value*Point[icol]
-	}
-	//test
-	if math.IsNaN(realhold) {
-		//test hhhm there is a problem with NaNs being generated
-		fmt.Println("Warning: NaN generated in ConBodyValue routine for function",FuncNum,LP.Rows[FuncNum].Name)
-		//test
+	if AccurateSums {
+		realhold = RowDot2(FuncNum, Point)
+	} else {
 		realhold = 0.0
-		if LP.Rows[FuncNum].NumEl <= 0 {fmt.Println("Number of elements in the row:",LP.Rows[FuncNum].NumEl)}
-		for i:=0; i<LP.Rows[FuncNum].NumEl; i++ {
-			ielem=LP.Rows[FuncNum].ElList[i]
-			icol=Element[ielem].Col
-			if math.IsNaN(Element[ielem].Value) {fmt.Println("Element",ielem,"is NaN")}
-			if math.IsNaN(Point[icol]) {fmt.Println("Point element",icol,"is NaN")}
-			realhold = realhold + Element[ielem].Value*Point[icol]
+		for k:=LP.RowStart[FuncNum]; k<LP.RowStart[FuncNum+1]; k++ {
+			realhold = realhold + LP.RowVal[k]*Point[LP.ColIdx[k]]
 		}
-		fmt.Println("NaN discovered in ConBodyVal for FuncNum",FuncNum,"at input point",Point)
-		os.Exit(1)
-		// check the input point
-		//for i:=0; i<LP.NumCols; i++ {
-		//	if Point[i] > Plinfy {fmt.Println("In ConBodyValue point element",i,"is too large:", Point[i])}
-		//} 
+	}
+	if len(LP.Rows[FuncNum].QTerms) > 0 {realhold = realhold + QuadValue(FuncNum, Point)}
+	if math.IsNaN(realhold) || math.IsInf(realhold, 0) {
+		// A NaN or infinity in the point or in a product. Let the caller discard the point
+		// rather than stopping the run: ReportBadBody will print the details if needed.
 		return realhold,2
-		//check whether anything is funky with the row values or Point values
-		//for i:=0; i<LP.Rows[FuncNum].NumEl; i++ {
-			//ielem=LP.Rows[FuncNum].ElList[i]
-			//icol=Element[ielem].Col
-			//fmt.Println("Row:",FuncNum,"Col:",icol,"Coefficient:",Element[ielem].Value,"Col coeff in Point:",Point[icol])
-		//}
 	}
-	if LP.Rows[FuncNum].Type=="N" {return realhold,1}
+	if LP.Rows[FuncNum].Type==RowN {return realhold,1}
 	return realhold,0
 } 
 //============================================================================================================
+// Prints the elements and point values responsible for a NaN or infinite body value in the given row.
+// Diagnostic companion to ConBodyValue, which only reports Status 2 in this case.
+func ReportBadBody(FuncNum int, Point []float64) {
+	var icol int
+	
+	if FuncNum < 0 || FuncNum > LP.NumRows-1 {return}
+	fmt.Println("Warning: non-finite body value in row",FuncNum,LP.Rows[FuncNum].Name)
+	if LP.Rows[FuncNum].NumEl <= 0 {fmt.Println("Number of elements in the row:",LP.Rows[FuncNum].NumEl)}
+	for k:=LP.RowStart[FuncNum]; k<LP.RowStart[FuncNum+1]; k++ {
+		icol=LP.ColIdx[k]
+		if math.IsNaN(LP.RowVal[k]) || math.IsInf(LP.RowVal[k], 0) {fmt.Println("  Element",k,"is",LP.RowVal[k])}
+		if math.IsNaN(Point[icol]) || math.IsInf(Point[icol], 0) {fmt.Println("  Point element",icol,"(",LP.Cols[icol].Name,") is",Point[icol])}
+	}
+}
+//============================================================================================================
 func EmptyMPS() (Status int) {
 	// Makes sure the MPS file structures are empty in case a new model is read in
 	
@@ -533,8 +764,10 @@ func EmptyMPS() (Status int) {
 		LP.Rows[i].NumEl=0
 		LP.Rows[i].RHSlo=0.0
 		LP.Rows[i].RHSup=0.0
-		LP.Rows[i].Type=""
+		LP.Rows[i].Type=RowN
 		LP.Rows[i].GradVecLenSq = 0.0
+		LP.Rows[i].InvGradVecLenSq = 0.0
+		LP.Rows[i].InvGradVecLen = 0.0
 		LP.Rows[i].ScaleFactor = 1.0
 		LP.Rows[i].ElList=nil
 	}
@@ -546,7 +779,7 @@ func EmptyMPS() (Status int) {
 		LP.Cols[i].BndUp=0.0
 		LP.Cols[i].Name=""
 		LP.Cols[i].NumEl=0
-		LP.Cols[i].Type=""
+		LP.Cols[i].Type=ColR
 		LP.Cols[i].ScaleFactor = 1.0
 		LP.Cols[i].ElList=nil
 	}
@@ -573,6 +806,8 @@ func EmptyMPS() (Status int) {
 	AvgElsPerRow=0.0; AvgElsPerCol=0.0 
 	
 	LP = EmptyLP
+	colIndex, rowIndex = nil, nil
+	exactReset()
 	
 	return 0
 }
@@ -594,16 +829,16 @@ func GetStatistics() () {
 	NumGRows=0; NumLRows=0; NumERows=0; NumNRows=0; NumRRows=0; AvgElsPerRow=0; MaxElsInRow=0; TotCons=0
 	for i:=0; i<LP.NumRows; i++ {
 		switch LP.Rows[i].Type {
-		case "G":
+		case RowG:
 			NumGRows++
 			if LP.Rows[i].RHSlo > -Plinfy {TotCons++}
-		case "L":
+		case RowL:
 			NumLRows++
 			if LP.Rows[i].RHSup < Plinfy {TotCons++}
-		case "E":
+		case RowE:
 			NumERows++
 			TotCons++
-		case "R":
+		case RowR:
 			NumRRows++
 			// Check that range hasn't been reversed
 			if LP.Rows[i].RHSlo > LP.Rows[i].RHSup {
@@ -617,33 +852,39 @@ func GetStatistics() () {
 				// The range is actually an equality
 				NumRRows = NumRRows - 1
 				NumERows= NumERows + 1
-				LP.Rows[i].Type = "E"
+				LP.Rows[i].Type = RowE
 				TotCons++
 			} else {
 				if LP.Rows[i].RHSlo > - Plinfy {TotCons++}
 				if LP.Rows[i].RHSup < Plinfy {TotCons++}
 			}
-		case "N":
+		case RowN:
 			NumNRows++
 		}
 		if LP.Rows[i].NumEl > MaxElsInRow {MaxElsInRow = LP.Rows[i].NumEl}
 		// Calculate the length of the gradient squared
-		rhold=0.0
-		for iel:=0; iel<LP.Rows[i].NumEl; iel++ {
-			rhold = rhold + Element[LP.Rows[i].ElList[iel]].Value*Element[LP.Rows[i].ElList[iel]].Value
-		}
-		LP.Rows[i].GradVecLenSq=rhold
+		SetGradVecLenSq(i, RowLenSq(i))
 	}
 	AvgElsPerRow=float64(NumElements)/float64(LP.NumRows)
+	NumQRows=0
+	for i:=0; i<LP.NumRows; i++ {
+		if len(LP.Rows[i].QTerms) > 0 && LP.Rows[i].Type != RowN {NumQRows++}
+	}
 	
 	// Look at columns
-	NumICols=0; NumRCols=0; NumICols=0; AvgElsPerCol=0; MaxElsInCol=0; TotBnds=0
+	NumICols=0; NumRCols=0; NumSCols=0; AvgElsPerCol=0; MaxElsInCol=0; TotBnds=0
 	for i:=0; i<LP.NumCols; i++ {
 		switch LP.Cols[i].Type {
-			case "R":
+			case ColR:
+				NumRCols++
+			case ColI:
+				NumICols++
+			case ColSC:
 				NumRCols++
-			case "I":
+				NumSCols++
+			case ColSI:
 				NumICols++
+				NumSCols++
 		}
 		if LP.Cols[i].NumEl > MaxElsInCol {MaxElsInCol = LP.Cols[i].NumEl}
 		// count the number of actual bounds and switch any reversed bounds
@@ -663,6 +904,111 @@ func GetStatistics() () {
 		}
 	}
 	AvgElsPerCol=float64(NumElements)/float64(LP.NumCols)
+	BuildCompressed()
+	return
+}
+//=============================================================================================
+// Stores the length of the gradient vector squared for a row, along with the derived
+// reciprocals that the solver uses in its inner loops.
+func SetGradVecLenSq(irow int, LenSq float64) {
+	LP.Rows[irow].GradVecLenSq = LenSq
+	if LenSq > 0.0 {
+		LP.Rows[irow].InvGradVecLenSq = 1.0/LenSq
+		LP.Rows[irow].InvGradVecLen = 1.0/math.Sqrt(LenSq)
+	} else {
+		LP.Rows[irow].InvGradVecLenSq = 0.0
+		LP.Rows[irow].InvGradVecLen = 0.0
+	}
+}
+//=============================================================================================
+// Converts an MPS row type code into a ROWTYPE. Found is false for an unknown code.
+func ParseRowType(Code string) (Type ROWTYPE, Found bool) {
+	switch strings.ToUpper(Code) {
+	case "N":
+		return RowN, true
+	case "G":
+		return RowG, true
+	case "L":
+		return RowL, true
+	case "E":
+		return RowE, true
+	}
+	return RowN, false
+}
+//=============================================================================================
+// Returns the MPS code for a row type, so row types print the way they appear in the file
+func (t ROWTYPE) String() string {
+	switch t {
+	case RowG:
+		return "G"
+	case RowL:
+		return "L"
+	case RowE:
+		return "E"
+	case RowR:
+		return "R"
+	}
+	return "N"
+}
+//=============================================================================================
+// Returns the one letter code for a column type
+func (t COLTYPE) String() string {
+	switch t {
+	case ColI:
+		return "I"
+	case ColSC:
+		return "SC"
+	case ColSI:
+		return "SI"
+	}
+	return "R"
+}
+//=============================================================================================
+// True for integer and semi-integer columns
+func (t COLTYPE) IsInteger() bool {
+	return t == ColI || t == ColSI
+}
+//=============================================================================================
+// True for semi-continuous and semi-integer columns, whose domain is {0} plus [BndLo, BndUp]
+func (t COLTYPE) IsSemi() bool {
+	return t == ColSC || t == ColSI
+}
+//=============================================================================================
+// Builds the compressed sparse row (CSR) and compressed sparse column (CSC) copies of the
+// constraint matrix from the Element triplets. The hot loops in the solver run over these
+// arrays because they are contiguous in memory, rather than going through ElList into Element.
+// Must be called again whenever the Element values change, e.g. after scaling.
+func BuildCompressed() {
+	var k int
+	
+	LP.RowStart = make([]int, LP.NumRows+1)
+	LP.ColIdx = make([]int, NumElements)
+	LP.RowVal = make([]float64, NumElements)
+	k = 0
+	for i:=0; i<LP.NumRows; i++ {
+		LP.RowStart[i] = k
+		for iel:=0; iel<LP.Rows[i].NumEl; iel++ {
+			LP.ColIdx[k] = Element[LP.Rows[i].ElList[iel]].Col
+			LP.RowVal[k] = Element[LP.Rows[i].ElList[iel]].Value
+			k++
+		}
+	}
+	LP.RowStart[LP.NumRows] = k
+	
+	LP.ColStart = make([]int, LP.NumCols+1)
+	LP.RowIdx = make([]int, NumElements)
+	LP.ColVal = make([]float64, NumElements)
+	k = 0
+	for j:=0; j<LP.NumCols; j++ {
+		LP.ColStart[j] = k
+		for iel:=0; iel<LP.Cols[j].NumEl; iel++ {
+			LP.RowIdx[k] = Element[LP.Cols[j].ElList[iel]].Row
+			LP.ColVal[k] = Element[LP.Cols[j].ElList[iel]].Value
+			k++
+		}
+	}
+	LP.ColStart[LP.NumCols] = k
+	buildQCols()
 	return
 }
 //=============================================================================================
@@ -691,6 +1037,10 @@ func PrintStatistics() {
 	fmt.Println(TotBnds, "Binding column bounds (equalities count as 1)")
 	fmt.Println("  ",NumRCols, "real-valued columns")
 	fmt.Println("  ",NumICols, "integer columns")
+	if NumSCols > 0 {fmt.Println("  ",NumSCols, "semi-continuous or semi-integer columns")}
+	if len(LP.SOS) > 0 {fmt.Println(len(LP.SOS), "SPECIAL ORDERED SETS")}
+	if NumQRows > 0 {fmt.Println(NumQRows, "QUADRATIC ROWS")}
+	if LP.ObjRow >= 0 && len(LP.Rows[LP.ObjRow].QTerms) > 0 {fmt.Println(len(LP.Rows[LP.ObjRow].QTerms), "QUADRATIC OBJECTIVE TERMS")}
 }
 //=============================================================================================
 // Scale the rows. Initially this is done by dividing through by the largest element
@@ -720,14 +1070,13 @@ func ScaleRows() {
 
 		//fmt.Println("Scale factor for row",irow,"is",MaxValue) // to look at row scales
 		// Now divide through by the largest element
-		rhold = 0.0 //use this to recalculate the length of the vector squared
 		for i:=0; i<LP.Rows[irow].NumEl; i++ {
 			iel = LP.Rows[irow].ElList[i]
 			Element[iel].Value = Element[iel].Value/MaxValue
-			rhold = rhold + Element[iel].Value*Element[iel].Value
 			if math.Abs(Element[iel].Value) < MinValueAfter {MinValueAfter = math.Abs(Element[iel].Value)}
 		}
-		LP.Rows[irow].GradVecLenSq = rhold
+		for i := range LP.Rows[irow].QTerms {LP.Rows[irow].QTerms[i].Value = LP.Rows[irow].QTerms[i].Value/MaxValue}
+		SetGradVecLenSq(irow, RowLenSq(irow)) // recalculate the length of the vector squared
 		// Now check on the RHS values, which may also need to be scaled by the same value
 		if LP.Rows[irow].RHSlo > -Plinfy && LP.Rows[irow].RHSlo < Plinfy {
 			LP.Rows[irow].RHSlo = LP.Rows[irow].RHSlo / MaxValue
@@ -742,6 +1091,7 @@ func ScaleRows() {
 	} else {
 		fmt.Println("No row scaling applied.")
 	}
+	BuildCompressed()
 	//os.Exit(1)  //useful to kill program if you just want to look at the row scales
 	return
 }
@@ -758,7 +1108,9 @@ func ScaleColumns() {
 	
 	ScaleApplied = false
 	MaxMaxValue = 0.0; MinValue = Plinfy; MinValueAfter = Plinfy
+	ColFactor := make([]float64, LP.NumCols) // the factor applied in this pass
 	for icol:=0; icol<LP.NumCols; icol++ {
+		ColFactor[icol] = 1.0
 		MaxValue = 0.0
 		for i:=0; i<LP.Cols[icol].NumEl; i++ {
 			iel = LP.Cols[icol].ElList[i]
@@ -771,6 +1123,7 @@ func ScaleColumns() {
 		
 		ScaleApplied = true
 		LP.Cols[icol].ScaleFactor = LP.Cols[icol].ScaleFactor * MaxValue // Note scale factor multiplies earlier scale value
+		ColFactor[icol] = MaxValue
 
 		//fmt.Println("Scale factor for row",irow,"is",MaxValue) // to look at row scales
 		// Now divide through by the largest element
@@ -787,14 +1140,13 @@ func ScaleColumns() {
 			LP.Cols[icol].BndUp = LP.Cols[icol].BndUp / MaxValue
 		}
 	}
-	//recalculate the row vector lengths squared
+	//recalculate the row vector lengths squared, and scale the quadratic terms like the elements
 	for irow:=0; irow<LP.NumRows; irow++ {
-		rhold = 0.0 //use this to recalculate the length of the vector squared
-		for i:=0; i<LP.Rows[irow].NumEl; i++ {
-			iel = LP.Rows[irow].ElList[i]
-			rhold = rhold + Element[iel].Value*Element[iel].Value
+		SetGradVecLenSq(irow, RowLenSq(irow))
+		for i := range LP.Rows[irow].QTerms {
+			Term := &LP.Rows[irow].QTerms[i]
+			Term.Value = Term.Value/(ColFactor[Term.Col1]*ColFactor[Term.Col2])
 		}
-		LP.Rows[irow].GradVecLenSq = rhold
 	}
 		
 	fmt.Println("Before column scaling: minimum A matrix element:",MinValue,"Maximum A matrix value:",MaxMaxValue,"Max/min:",MaxMaxValue/MinValue)
@@ -803,6 +1155,7 @@ func ScaleColumns() {
 	} else {
 		fmt.Println("No column Scaling applied.")
 	}
+	BuildCompressed()
 	//os.Exit(1)  //useful to kill program if you just want to look at the row scales
 	return
 }
@@ -0,0 +1,238 @@
+package lp
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var benchSink float64 // Keeps the benchmarked sums from being optimized away
+
+//=======================================================================================
+// Builds a random sparse model with NumRows rows of NumPerRow elements over NumCols columns,
+// and returns a point inside the column bounds
+func buildSparseModel(tb testing.TB, NumRows int, NumCols int, NumPerRow int) []float64 {
+	tb.Helper()
+	Rand := rand.New(rand.NewSource(1))
+	NewModel("SPARSE", 1.0e10, 1.0e-6)
+	for j := 0; j < NumCols; j++ {
+		AddColumn("C"+strconv.Itoa(j), ColR, -10.0, 10.0)
+	}
+	for i := 0; i < NumRows; i++ {
+		irow, _ := AddRow("R"+strconv.Itoa(i), RowL, 1.0, 0.0)
+		for k := 0; k < NumPerRow; k++ {
+			SetCoefficient(irow, Rand.Intn(NumCols), Rand.NormFloat64())
+		}
+	}
+	for j := 0; j < NumCols; j++ {
+		if LP.Cols[j].NumEl == 0 {
+			// EndModel reports empty columns
+			SetCoefficient(j%NumRows, j, Rand.NormFloat64())
+		}
+	}
+	if EndModel() > 0 {
+		tb.Fatal("EndModel failed")
+	}
+	Point := make([]float64, NumCols)
+	for j := range Point {
+		Point[j] = 20.0*Rand.Float64() - 10.0
+	}
+	return Point
+}
+
+//=======================================================================================
+// Row body through the ElList/Element triplets, as it was computed before the CSR arrays
+func elementBody(irow int, Point []float64) float64 {
+	var Sum float64
+	for _, iel := range LP.Rows[irow].ElList {
+		Sum = Sum + Element[iel].Value*Point[Element[iel].Col]
+	}
+	return Sum
+}
+
+//=======================================================================================
+// The CSR arrays hold the same rows as the element lists
+func TestConBodyValueMatchesElements(t *testing.T) {
+	Point := buildSparseModel(t, 200, 300, 8)
+	for i := 0; i < LP.NumRows; i++ {
+		Body, Status := ConBodyValue(i, Point)
+		if Status != 0 {
+			t.Fatalf("row %s: status %d", LP.Rows[i].Name, Status)
+		}
+		if Want := elementBody(i, Point); math.Abs(Body-Want) > 1.0e-12*(1.0+math.Abs(Want)) {
+			t.Errorf("row %s: CSR body %v, element body %v", LP.Rows[i].Name, Body, Want)
+		}
+	}
+}
+
+//=======================================================================================
+func BenchmarkConBodyValueElement(b *testing.B) {
+	Point := buildSparseModel(b, 5000, 10000, 20)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < LP.NumRows; i++ {
+			benchSink = benchSink + elementBody(i, Point)
+		}
+	}
+}
+
+//=======================================================================================
+func BenchmarkConBodyValueCSR(b *testing.B) {
+	Point := buildSparseModel(b, 5000, 10000, 20)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < LP.NumRows; i++ {
+			Body, _ := ConBodyValue(i, Point)
+			benchSink = benchSink + Body
+		}
+	}
+}
+
+//=======================================================================================
+// A one-row model whose column X, an integer column if Integer, has the given BOUNDS lines
+func boundsMPS(Integer bool, Bounds string) string {
+	Column := "    X         COST      1            R1        1\n"
+	if Integer {
+		Column = "    MARKER                 'MARKER'                 'INTORG'\n" + Column +
+			"    MARKER                 'MARKER'                 'INTEND'\n"
+	}
+	return "NAME          BOUNDTEST\nROWS\n N  COST\n L  R1\nCOLUMNS\n" + Column +
+		"RHS\n    RHSV      R1        10\nBOUNDS\n" + Bounds + "ENDATA\n"
+}
+
+//=======================================================================================
+func TestReadSemiContinuous(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Integer bool
+		Bounds  string
+		Type    COLTYPE
+		Lo, Up  float64
+		ExactUp string
+	}{
+		{"SC bound", false, " SC BND       X         4.5\n", ColSC, 0.0, 4.5, "9/2"},
+		{"SC bound without a value", false, " SC BND       X\n", ColSC, 0.0, 1.0e10, "inf"},
+		{"SC bound on an integer column", true, " SC BND       X         7\n", ColSI, 0.0, 7.0, "7"},
+		{"SC bound after a lower bound", false, " LO BND       X         2\n SC BND       X         4.5\n", ColSC, 2.0, 4.5, "9/2"},
+		{"no SC bound", false, " UP BND       X         4.5\n", ColR, 0.0, 4.5, "9/2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			readMPSText(t, boundsMPS(tt.Integer, tt.Bounds))
+			Col := LP.Cols[0]
+			if Col.Type != tt.Type || Col.BndLo != tt.Lo || Col.BndUp != tt.Up {
+				t.Errorf("type %v, bounds [%v, %v], want %v, [%v, %v]", Col.Type, Col.BndLo, Col.BndUp, tt.Type, tt.Lo, tt.Up)
+			}
+			if Up := ratText(ExactCols[0].Up); Up != tt.ExactUp {
+				t.Errorf("exact upper bound %s, want %s", Up, tt.ExactUp)
+			}
+			WantSemi := 0
+			if tt.Type.IsSemi() {
+				WantSemi = 1
+			}
+			if NumSCols != WantSemi {
+				t.Errorf("%d semi-continuous columns counted, want %d", NumSCols, WantSemi)
+			}
+		})
+	}
+}
+
+//=======================================================================================
+// Each layout of the SOS section gives the same sets, members in weight order
+func TestReadSOS(t *testing.T) {
+	type sosWant struct {
+		Name     string
+		Type     int
+		Priority int
+		Cols     []string
+		Weights  []float64
+	}
+	tests := []struct {
+		Name string
+		SOS  string
+		Want []sosWant
+	}{
+		{"Gurobi layout", " S1 SOS1\n    X1        1\n    X2        2\n",
+			[]sosWant{{"SOS1", 1, 0, []string{"X1", "X2"}, []float64{1, 2}}}},
+		{"CPLEX layout with name:priority", " S2 SOS       SET1:5\n    SET1      X1:1.5\n    SET1      X3:2.5\n",
+			[]sosWant{{"SET1", 2, 5, []string{"X1", "X3"}, []float64{1.5, 2.5}}}},
+		{"name and priority as two fields", " S1 SOS       SET1 3\n    X2:4\n    X1:7\n",
+			[]sosWant{{"SET1", 1, 3, []string{"X2", "X1"}, []float64{4, 7}}}},
+		{"members put in weight order", " S2 SOS\n    X1        3\n    X2        1\n    X3        2\n",
+			[]sosWant{{"SOS1", 2, 0, []string{"X2", "X3", "X1"}, []float64{1, 2, 3}}}},
+		{"members without weights", " S2 SOS\n    X3\n    X1\n",
+			[]sosWant{{"SOS1", 2, 0, []string{"X3", "X1"}, []float64{1, 2}}}},
+		{"two sets", " S1 SOS       A\n    X1        1\n    X2        2\n S2 SOS       B\n    X2        1\n    X3        2\n",
+			[]sosWant{{"A", 1, 0, []string{"X1", "X2"}, []float64{1, 2}}, {"B", 2, 0, []string{"X2", "X3"}, []float64{1, 2}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			readMPSText(t, "NAME          SOSTEST\nROWS\n N  COST\n L  R1\nCOLUMNS\n"+
+				"    X1        COST      1            R1        1\n"+
+				"    X2        COST      1            R1        1\n"+
+				"    X3        COST      1            R1        1\n"+
+				"RHS\n    RHSV      R1        10\nSOS\n"+tt.SOS+"ENDATA\n")
+			var Got []sosWant
+			for _, Set := range LP.SOS {
+				Names := []string{}
+				for _, j := range Set.Cols {
+					Names = append(Names, LP.Cols[j].Name)
+				}
+				Got = append(Got, sosWant{Set.Name, Set.Type, Set.Priority, Names, Set.Weights})
+			}
+			if !reflect.DeepEqual(Got, tt.Want) {
+				t.Errorf("sets %+v, want %+v", Got, tt.Want)
+			}
+		})
+	}
+}
+
+//=======================================================================================
+// The objective is 0.5 x'Qx whether Q comes as its upper triangle (QUADOBJ) or whole (QMATRIX);
+// a constraint's QCMATRIX gives x'Qx itself. The linear parts are x + y in both rows.
+func TestReadQuadratic(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Sections   string
+		ObjBody    float64 // at x = 1, y = 2
+		RowBody    float64
+		NumQRows   int
+		ExactTerms []string // of R1
+	}{
+		{"QUADOBJ", "QUADOBJ\n    X         X         2\n    X         Y         3\n    Y         Y         4\n",
+			3.0 + 15.0, 3.0, 0, nil},
+		{"QMATRIX", "QMATRIX\n    X         X         2\n    X         Y         3\n    Y         X         3\n    Y         Y         4\n",
+			3.0 + 15.0, 3.0, 0, nil},
+		{"QCMATRIX", "QCMATRIX   R1\n    X         X         2\n    X         Y         0.5\n    Y         X         0.5\n    Y         Y         4\n",
+			3.0, 3.0 + 20.0, 1, []string{"2", "1/2", "1/2", "4"}},
+		{"QCMATRIX for an unknown row", "QCMATRIX   R9\n    X         X         2\n",
+			3.0, 3.0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			readMPSText(t, "NAME          QTEST\nROWS\n N  COST\n L  R1\nCOLUMNS\n"+
+				"    X         COST      1            R1        1\n"+
+				"    Y         COST      1            R1        1\n"+
+				"RHS\n    RHSV      R1        10\n"+tt.Sections+"ENDATA\n")
+			Point := []float64{1.0, 2.0}
+			if Body, _ := ConBodyValue(LP.ObjRow, Point); Body != tt.ObjBody {
+				t.Errorf("objective %v, want %v", Body, tt.ObjBody)
+			}
+			if Body, _ := ConBodyValue(1, Point); Body != tt.RowBody {
+				t.Errorf("row R1 %v, want %v", Body, tt.RowBody)
+			}
+			if NumQRows != tt.NumQRows {
+				t.Errorf("%d quadratic rows, want %d", NumQRows, tt.NumQRows)
+			}
+			var Exact []string
+			for _, r := range ExactQ[1] {
+				Exact = append(Exact, ratText(r))
+			}
+			if !reflect.DeepEqual(Exact, tt.ExactTerms) {
+				t.Errorf("exact terms of R1 %v, want %v", Exact, tt.ExactTerms)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package lp
+
+// Changes to the model after it has been read or built. The solver appends rows (e.g. an
+// objective cut) and removes them again once they are no longer needed, and sets quadratic
+// terms on a row. A model that is solved, changed a little and solved again is changed in
+// place with SetRowBounds, SetColumnBounds and SetRowCoefficients, and a cut is added with
+// AppendRow. Each of these calls GetStatistics, which brings the derived data (gradient
+// lengths, counts and the CSR/CSC arrays) up to date, so the model is ready for the solver as
+// soon as they return. The values are in the model's own terms: if ScaleRows or ScaleColumns
+// has been called, they must be scaled by the caller.
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+//=============================================================================================
+// Appends a row with the given type, bounds and nonzeros (column numbers Cols, values Vals).
+// The bounds follow the reader's conventions: G rows use RHSlo, L rows RHSup, E rows both.
+// Status: 0(success), 1(bad column number or mismatched lists)
+func AppendRow(Name string, Type ROWTYPE, RHSlo float64, RHSup float64, Cols []int, Vals []float64) (irow int, Status int) {
+	if len(Cols) != len(Vals) {
+		fmt.Println("Error: AppendRow given",len(Cols),"columns but",len(Vals),"values.")
+		return -1, 1
+	}
+	for _, j := range Cols {
+		if j < 0 || j >= LP.NumCols {
+			fmt.Println("Error: AppendRow given column number",j,"but the model has",LP.NumCols,"columns.")
+			return -1, 1
+		}
+	}
+	irow = LP.NumRows
+	var Row ROW
+	Row.Name = Name
+	Row.Type = Type
+	Row.RHSlo = RHSlo
+	Row.RHSup = RHSup
+	Row.ScaleFactor = 1.0
+	LP.Rows = append(LP.Rows, Row)
+	LP.NumRows++
+	rowIndex = nil
+	if KeepExact {
+		Bnds := EXACTBOUNDS{exactDecimal(RHSlo), exactDecimal(RHSup)}
+		ExactRows = append(ExactRows, Bnds)
+	}
+	for k, j := range Cols {
+		Element = append(Element, ELEMENT{Row: irow, Col: j, Value: Vals[k]})
+		NumElements++
+		if KeepExact {ExactEl = append(ExactEl, ParseExact(exactText(Vals[k])))}
+		LP.Rows[irow].ElList = append(LP.Rows[irow].ElList, NumElements-1)
+		LP.Rows[irow].NumEl++
+		LP.Cols[j].ElList = append(LP.Cols[j].ElList, NumElements-1)
+		LP.Cols[j].NumEl++
+	}
+	GetStatistics()
+	return irow, 0
+}
+//=============================================================================================
+// Removes the last row of the model, which must have been added by AppendRow after every
+// other change to the elements, so that its elements are the last ones.
+func DeleteLastRow() {
+	if LP.NumRows == 0 {return}
+	irow := LP.NumRows-1
+	for iel:=LP.Rows[irow].NumEl-1; iel>=0; iel-- {
+		j := Element[LP.Rows[irow].ElList[iel]].Col
+		LP.Cols[j].ElList = LP.Cols[j].ElList[:LP.Cols[j].NumEl-1]
+		LP.Cols[j].NumEl--
+	}
+	NumElements = NumElements - LP.Rows[irow].NumEl
+	Element = Element[:NumElements]
+	if len(ExactEl) > NumElements {ExactEl = ExactEl[:NumElements]}
+	if len(ExactRows) > irow {ExactRows = ExactRows[:irow]}
+	delete(ExactQ, irow)
+	LP.Rows = LP.Rows[:irow]
+	LP.NumRows--
+	rowIndex = nil
+	GetStatistics()
+}
+//=============================================================================================
+// Replaces the quadratic terms of row irow, e.g. to give an objective cut the quadratic part
+// of the objective.
+// Status: 0(success), 1(bad row or column number)
+func SetQuadTerms(irow int, Terms []QTERM) (Status int) {
+	if irow < 0 || irow >= LP.NumRows {
+		fmt.Println("Error: SetQuadTerms given row number",irow,"but the model has",LP.NumRows,"rows.")
+		return 1
+	}
+	for _, Term := range Terms {
+		if Term.Col1 < 0 || Term.Col1 >= LP.NumCols || Term.Col2 < 0 || Term.Col2 >= LP.NumCols {
+			fmt.Println("Error: SetQuadTerms given a term on columns",Term.Col1,Term.Col2,"but the model has",LP.NumCols,"columns.")
+			return 1
+		}
+	}
+	LP.Rows[irow].QTerms = append([]QTERM(nil), Terms...)
+	if KeepExact {
+		delete(ExactQ, irow)
+		if len(Terms) > 0 {
+			if ExactQ == nil {ExactQ = make(map[int][]*big.Rat)}
+			for _, Term := range Terms {ExactQ[irow] = append(ExactQ[irow], ParseExact(exactText(Term.Value)))}
+		}
+	}
+	GetStatistics()
+	return 0
+}
+//=============================================================================================
+// Changes the bounds on the body of row irow. The row type follows from the bounds: G if only
+// Lo is finite (or neither is), L if only Up is, E if they are equal and R otherwise.
+// Status: 0(success), 1(bad row number, nonbinding row or Lo above Up)
+func SetRowBounds(irow int, Lo float64, Up float64) (Status int) {
+	if irow < 0 || irow >= LP.NumRows {
+		fmt.Println("Error: SetRowBounds given row number",irow,"but the model has",LP.NumRows,"rows.")
+		return 1
+	}
+	if LP.Rows[irow].Type == RowN {
+		fmt.Println("Error: row",LP.Rows[irow].Name,"is nonbinding, so its bounds can't be changed.")
+		return 1
+	}
+	if Lo > Up {
+		fmt.Println("Error: SetRowBounds given lower bound",Lo,"above upper bound",Up,"for row",LP.Rows[irow].Name)
+		return 1
+	}
+	Lo, Up = math.Max(Lo, -Plinfy), math.Min(Up, Plinfy)
+	Row := &LP.Rows[irow]
+	Row.RHSlo, Row.RHSup = Lo, Up
+	switch {
+	case Up >= Plinfy:
+		Row.Type = RowG
+	case Lo <= -Plinfy:
+		Row.Type = RowL
+	case Lo == Up:
+		Row.Type = RowE
+	default:
+		Row.Type = RowR
+	}
+	if KeepExact {ExactRows[irow] = EXACTBOUNDS{exactDecimal(Lo), exactDecimal(Up)}}
+	GetStatistics()
+	return 0
+}
+//=============================================================================================
+// Changes the bounds of column icol. For a semi-continuous or semi-integer column they are the
+// bounds of the part of its domain other than 0.
+// Status: 0(success), 1(bad column number or Lo above Up)
+func SetColumnBounds(icol int, Lo float64, Up float64) (Status int) {
+	if icol < 0 || icol >= LP.NumCols {
+		fmt.Println("Error: SetColumnBounds given column number",icol,"but the model has",LP.NumCols,"columns.")
+		return 1
+	}
+	if Lo > Up {
+		fmt.Println("Error: SetColumnBounds given lower bound",Lo,"above upper bound",Up,"for column",LP.Cols[icol].Name)
+		return 1
+	}
+	Lo, Up = math.Max(Lo, -Plinfy), math.Min(Up, Plinfy)
+	LP.Cols[icol].BndLo, LP.Cols[icol].BndUp = Lo, Up
+	if KeepExact {ExactCols[icol] = EXACTBOUNDS{exactDecimal(Lo), exactDecimal(Up)}}
+	GetStatistics()
+	return 0
+}
+//=============================================================================================
+// Sets the coefficients of columns Cols in row irow to Vals, adding elements for the columns
+// that aren't in the row yet. A coefficient set to 0 keeps its element. The objective is
+// changed by giving LP.ObjRow. An added element goes after every other, so a row appended by
+// AppendRow can no longer be removed by DeleteLastRow once elements are added to another row.
+// Status: 0(success), 1(bad row or column number or mismatched lists)
+func SetRowCoefficients(irow int, Cols []int, Vals []float64) (Status int) {
+	if len(Cols) != len(Vals) {
+		fmt.Println("Error: SetRowCoefficients given",len(Cols),"columns but",len(Vals),"values.")
+		return 1
+	}
+	for k, j := range Cols {
+		if SetCoefficient(irow, j, Vals[k]) > 0 {
+			GetStatistics() // for the coefficients already set
+			return 1
+		}
+	}
+	GetStatistics()
+	return 0
+}
+//=============================================================================================
+// Exact value of a bound given by the caller: its shortest decimal form, as the builder takes it,
+// with nil for an infinite bound. Coefficients go through ParseExact(exactText(...)) directly,
+// since a large one is not infinite.
+func exactDecimal(Value float64) *big.Rat {
+	if Value <= -Plinfy || Value >= Plinfy {return nil}
+	return ParseExact(exactText(Value))
+}
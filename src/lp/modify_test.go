@@ -0,0 +1,179 @@
+package lp
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+//=======================================================================================
+// Builds R1: x + 2y >= 1 and R2: x - y <= 3 over x and y in [0, 10], with the objective x + y
+func buildSmallModel(tb testing.TB, Exact bool) (x int, y int) {
+	tb.Helper()
+	Save := KeepExact
+	KeepExact = Exact
+	tb.Cleanup(func() { KeepExact = Save })
+
+	NewModel("SMALL", 1.0e10, 1.0e-6)
+	x, _ = AddColumn("X", ColR, 0.0, 10.0)
+	y, _ = AddColumn("Y", ColR, 0.0, 10.0)
+	SetObjective([]int{x, y}, []float64{1.0, 1.0})
+	R1, _ := AddRow("R1", RowG, 1.0, 0.0)
+	SetCoefficient(R1, x, 1.0)
+	SetCoefficient(R1, y, 2.0)
+	R2, _ := AddRow("R2", RowL, 3.0, 0.0)
+	SetCoefficient(R2, x, 1.0)
+	SetCoefficient(R2, y, -1.0)
+	if EndModel() > 0 {
+		tb.Fatal("EndModel failed")
+	}
+	return x, y
+}
+
+//=======================================================================================
+// A bound or coefficient gets the same exact value whichever routine sets it: the shortest
+// decimal, as in the builder
+func TestAppendRowExactData(t *testing.T) {
+	x, y := buildSmallModel(t, true)
+	Tenth := big.NewRat(1, 10)
+	irow, Status := AppendRow("CUT", RowL, -Plinfy, 0.1, []int{x, y}, []float64{0.1, 0.3})
+	if Status != 0 {
+		t.Fatalf("AppendRow status %d", Status)
+	}
+	if Up := ExactRows[irow].Up; Up == nil || Up.Cmp(Tenth) != 0 {
+		t.Errorf("AppendRow: exact upper bound %v, want 1/10", Up)
+	}
+	if Lo := ExactRows[irow].Lo; Lo != nil {
+		t.Errorf("AppendRow: exact lower bound %v, want none", Lo)
+	}
+	if El := ExactEl[LP.Rows[irow].ElList[0]]; El.Cmp(Tenth) != 0 {
+		t.Errorf("AppendRow: exact element %v, want 1/10", El)
+	}
+	// A coefficient beyond Plinfy is large, not infinite
+	Big, _ := AppendRow("BIG", RowL, -Plinfy, 1.0, []int{x}, []float64{2.0e10})
+	if El := ExactEl[LP.Rows[Big].ElList[0]]; El == nil || El.Cmp(big.NewRat(2.0e10, 1)) != 0 {
+		t.Errorf("AppendRow: exact element %v, want 20000000000", El)
+	}
+	SetRowBounds(irow, -Plinfy, 0.1)
+	if Up := ExactRows[irow].Up; Up == nil || Up.Cmp(Tenth) != 0 {
+		t.Errorf("SetRowBounds: exact upper bound %v, want 1/10", Up)
+	}
+}
+
+//=======================================================================================
+// Checks the derived data of the model against its rows, given by name as coefficients by
+// column name: the CSR and CSC arrays, the element counts, the gradient lengths, the name
+// index and the exact elements
+func checkDerived(t *testing.T, Want map[string]map[string]float64) {
+	t.Helper()
+	if LP.NumRows != len(Want) {
+		t.Fatalf("%d rows, want %d", LP.NumRows, len(Want))
+	}
+	NumWant := 0
+	for i := 0; i < LP.NumRows; i++ {
+		Row := &LP.Rows[i]
+		WantRow, Found := Want[Row.Name]
+		if !Found {
+			t.Fatalf("unexpected row %s", Row.Name)
+		}
+		if irow, Found := RowNumber(Row.Name); !Found || irow != i {
+			t.Errorf("RowNumber(%s) = %d, want %d", Row.Name, irow, i)
+		}
+		Got := make(map[string]float64)
+		for k := LP.RowStart[i]; k < LP.RowStart[i+1]; k++ {
+			Got[LP.Cols[LP.ColIdx[k]].Name] = LP.RowVal[k]
+		}
+		if !reflect.DeepEqual(Got, WantRow) {
+			t.Errorf("row %s: CSR %v, want %v", Row.Name, Got, WantRow)
+		}
+		if Row.NumEl != len(WantRow) || len(Row.ElList) != len(WantRow) {
+			t.Errorf("row %s: NumEl %d and %d in ElList, want %d", Row.Name, Row.NumEl, len(Row.ElList), len(WantRow))
+		}
+		var LenSq float64
+		for _, Value := range WantRow {
+			LenSq = LenSq + Value*Value
+		}
+		if math.Abs(Row.GradVecLenSq-LenSq) > 1.0e-15*LenSq || math.Abs(Row.InvGradVecLen*math.Sqrt(LenSq)-1.0) > 1.0e-15 {
+			t.Errorf("row %s: GradVecLenSq %v and InvGradVecLen %v, want %v", Row.Name, Row.GradVecLenSq, Row.InvGradVecLen, LenSq)
+		}
+		NumWant = NumWant + len(WantRow)
+	}
+	NumCSC := 0
+	for j := 0; j < LP.NumCols; j++ {
+		if LP.Cols[j].NumEl != LP.ColStart[j+1]-LP.ColStart[j] {
+			t.Errorf("column %s: NumEl %d, %d in the CSC arrays", LP.Cols[j].Name, LP.Cols[j].NumEl, LP.ColStart[j+1]-LP.ColStart[j])
+		}
+		for k := LP.ColStart[j]; k < LP.ColStart[j+1]; k++ {
+			RowName := LP.Rows[LP.RowIdx[k]].Name
+			if Value, Found := Want[RowName][LP.Cols[j].Name]; !Found || Value != LP.ColVal[k] {
+				t.Errorf("column %s: CSC has %v in row %s", LP.Cols[j].Name, LP.ColVal[k], RowName)
+			}
+			NumCSC++
+		}
+	}
+	if NumElements != NumWant || NumCSC != NumWant || len(Element) != NumWant {
+		t.Errorf("%d elements, %d in the CSC arrays and %d in Element, want %d", NumElements, NumCSC, len(Element), NumWant)
+	}
+	if len(ExactEl) != NumElements {
+		t.Errorf("%d exact elements for %d elements", len(ExactEl), NumElements)
+	}
+}
+
+//=======================================================================================
+func TestModifyKeepsDerivedData(t *testing.T) {
+	row := func(Name string) int {
+		irow, _ := RowNumber(Name)
+		return irow
+	}
+	Base := map[string]map[string]float64{
+		"OBJ": {"X": 1.0, "Y": 1.0},
+		"R1":  {"X": 1.0, "Y": 2.0},
+		"R2":  {"X": 1.0, "Y": -1.0},
+	}
+	tests := []struct {
+		Name   string
+		Change func(x, y int)
+		Want   map[string]map[string]float64 // rows that differ from Base, nil for a deleted row
+	}{
+		{"change a coefficient", func(x, y int) {
+			SetRowCoefficients(row("R1"), []int{x}, []float64{3.0})
+		}, map[string]map[string]float64{"R1": {"X": 3.0, "Y": 2.0}}},
+		{"coefficient set to 0 keeps its element", func(x, y int) {
+			SetRowCoefficients(row("R2"), []int{y}, []float64{0.0})
+		}, map[string]map[string]float64{"R2": {"X": 1.0, "Y": 0.0}}},
+		{"change the objective", func(x, y int) {
+			SetRowCoefficients(LP.ObjRow, []int{x, y}, []float64{-2.0, 0.5})
+		}, map[string]map[string]float64{"OBJ": {"X": -2.0, "Y": 0.5}}},
+		{"append a row", func(x, y int) {
+			AppendRow("CUT", RowL, -Plinfy, 4.0, []int{x, y}, []float64{3.0, 4.0})
+		}, map[string]map[string]float64{"CUT": {"X": 3.0, "Y": 4.0}}},
+		{"add an element to an appended row", func(x, y int) {
+			AppendRow("CUT", RowL, -Plinfy, 4.0, []int{x}, []float64{3.0})
+			SetRowCoefficients(row("CUT"), []int{y}, []float64{4.0})
+		}, map[string]map[string]float64{"CUT": {"X": 3.0, "Y": 4.0}}},
+		{"append and delete a row", func(x, y int) {
+			AppendRow("CUT", RowL, -Plinfy, 4.0, []int{x, y}, []float64{3.0, 4.0})
+			DeleteLastRow()
+		}, nil},
+		{"change a row, then append and delete another", func(x, y int) {
+			SetRowCoefficients(row("R1"), []int{y}, []float64{-5.0})
+			AppendRow("CUT", RowG, 1.0, Plinfy, []int{y}, []float64{2.0})
+			DeleteLastRow()
+		}, map[string]map[string]float64{"R1": {"X": 1.0, "Y": -5.0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			x, y := buildSmallModel(t, true)
+			tt.Change(x, y)
+			Want := make(map[string]map[string]float64)
+			for Name, Coefs := range Base {
+				Want[Name] = Coefs
+			}
+			for Name, Coefs := range tt.Want {
+				Want[Name] = Coefs
+			}
+			checkDerived(t, Want)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package lp
+
+// The quadratic part of a row body, read from the QUADOBJ, QMATRIX and QCMATRIX sections.
+// Rows without quadratic terms don't go through these routines, so the linear code paths are
+// unchanged.
+
+import "sort"
+
+//=============================================================================================
+// Value of the quadratic part of the body of row irow at Point
+func QuadValue(irow int, Point []float64) (Value float64) {
+	for _, Term := range LP.Rows[irow].QTerms {
+		Value = Value + Term.Value*Point[Term.Col1]*Point[Term.Col2]
+	}
+	return Value
+}
+//=============================================================================================
+// Gradient of the body of row irow at Point, linear and quadratic parts together. Grad is
+// indexed by column: only the entries for the columns in Rows[irow].QCols are set, so the
+// caller can reuse one slice for every row. Returns the squared length of the gradient.
+func QuadGradient(irow int, Point []float64, Grad []float64) (LenSq float64) {
+	for _, j := range LP.Rows[irow].QCols {Grad[j] = 0.0}
+	for k:=LP.RowStart[irow]; k<LP.RowStart[irow+1]; k++ {
+		Grad[LP.ColIdx[k]] = Grad[LP.ColIdx[k]] + LP.RowVal[k]
+	}
+	for _, Term := range LP.Rows[irow].QTerms {
+		Grad[Term.Col1] = Grad[Term.Col1] + Term.Value*Point[Term.Col2]
+		Grad[Term.Col2] = Grad[Term.Col2] + Term.Value*Point[Term.Col1]
+	}
+	for _, j := range LP.Rows[irow].QCols {LenSq = LenSq + Grad[j]*Grad[j]}
+	return LenSq
+}
+//=============================================================================================
+// Fills in QCols for the rows with quadratic terms. Called by BuildCompressed.
+func buildQCols() {
+	var Mark []bool
+	for i:=0; i<LP.NumRows; i++ {
+		LP.Rows[i].QCols = nil
+		if len(LP.Rows[i].QTerms) == 0 {continue}
+		if Mark == nil {Mark = make([]bool, LP.NumCols)}
+		for k:=LP.RowStart[i]; k<LP.RowStart[i+1]; k++ {Mark[LP.ColIdx[k]] = true}
+		for _, Term := range LP.Rows[i].QTerms {
+			Mark[Term.Col1] = true
+			Mark[Term.Col2] = true
+		}
+		for k:=LP.RowStart[i]; k<LP.RowStart[i+1]; k++ {LP.Rows[i].QCols = markedCol(LP.Rows[i].QCols, Mark, LP.ColIdx[k])}
+		for _, Term := range LP.Rows[i].QTerms {
+			LP.Rows[i].QCols = markedCol(LP.Rows[i].QCols, Mark, Term.Col1)
+			LP.Rows[i].QCols = markedCol(LP.Rows[i].QCols, Mark, Term.Col2)
+		}
+		sort.Ints(LP.Rows[i].QCols)
+	}
+}
+//=============================================================================================
+// Appends column j to Cols the first time it is seen, clearing its mark
+func markedCol(Cols []int, Mark []bool, j int) []int {
+	if !Mark[j] {return Cols}
+	Mark[j] = false
+	return append(Cols, j)
+}
@@ -0,0 +1,59 @@
+package lp
+
+// Compensated summation for the row evaluations. With AccurateSums set, row bodies and
+// gradient lengths are computed with the Dot2 algorithm of Ogita, Rump and Oishi: each product
+// is split exactly into a float64 and its rounding error (TwoProduct, using a fused
+// multiply-add), and the sum is accumulated with Neumaier-style error terms (TwoSum). The
+// result is as accurate as if it had been computed in twice the working precision and then
+// rounded, so cancellation on long rows no longer produces spurious violations. It costs
+// roughly three to four times a plain dot product: see BenchmarkConBodyValueAccurate.
+
+import (
+	"math"
+)
+
+var AccurateSums bool // true: use compensated dot products in ConBodyValue and RowLenSq
+
+//=============================================================================================
+// Error-free transformation of a sum: a+b = s+e exactly
+func TwoSum(a float64, b float64) (s float64, e float64) {
+	s = a + b
+	bb := s - a
+	e = (a - (s - bb)) + (b - bb)
+	return s, e
+}
+//=============================================================================================
+// Error-free transformation of a product: a*b = p+e exactly (barring underflow)
+func TwoProduct(a float64, b float64) (p float64, e float64) {
+	p = a * b
+	e = math.FMA(a, b, -p)
+	return p, e
+}
+//=============================================================================================
+// Compensated dot product of a CSR row with a point
+func RowDot2(FuncNum int, Point []float64) float64 {
+	var s, c, p, ep, e float64
+	for k:=LP.RowStart[FuncNum]; k<LP.RowStart[FuncNum+1]; k++ {
+		p, ep = TwoProduct(LP.RowVal[k], Point[LP.ColIdx[k]])
+		s, e = TwoSum(s, p)
+		c = c + (e + ep)
+	}
+	return s + c
+}
+//=============================================================================================
+// Length of the gradient vector squared for a row, from the Element values (so it can be used
+// before the CSR arrays are built). Compensated if AccurateSums is set.
+func RowLenSq(irow int) float64 {
+	var s, c, p, ep, e, v float64
+	for iel:=0; iel<LP.Rows[irow].NumEl; iel++ {
+		v = Element[LP.Rows[irow].ElList[iel]].Value
+		if !AccurateSums {
+			s = s + v*v
+			continue
+		}
+		p, ep = TwoProduct(v, v)
+		s, e = TwoSum(s, p)
+		c = c + (e + ep)
+	}
+	return s + c
+}
@@ -0,0 +1,79 @@
+package lp
+
+import (
+	"math"
+	"testing"
+)
+
+//=======================================================================================
+// Sets AccurateSums for the rest of the test and restores it afterwards
+func setAccurateSums(tb testing.TB, On bool) {
+	tb.Helper()
+	Save := AccurateSums
+	AccurateSums = On
+	tb.Cleanup(func() { AccurateSums = Save })
+}
+
+//=======================================================================================
+// A row whose terms cancel: the plain sum loses the 1 entirely, the compensated one keeps it
+func TestAccurateSumsCancellation(t *testing.T) {
+	NewModel("CANCEL", 1.0e10, 1.0e-6)
+	for _, Name := range []string{"X", "Y", "Z"} {
+		AddColumn(Name, ColR, -1.0e10, 1.0e10)
+	}
+	irow, _ := AddRow("R", RowL, 1.0, 0.0)
+	SetCoefficient(irow, 0, 1.0e16)
+	SetCoefficient(irow, 1, 1.0)
+	SetCoefficient(irow, 2, -1.0e16)
+	if EndModel() > 0 {
+		t.Fatal("EndModel failed")
+	}
+	Point := []float64{1.0, 1.0, 1.0}
+
+	tests := []struct {
+		Accurate bool
+		Want     float64
+	}{
+		{false, 0.0},
+		{true, 1.0},
+	}
+	for _, tt := range tests {
+		setAccurateSums(t, tt.Accurate)
+		if Body, _ := ConBodyValue(irow, Point); Body != tt.Want {
+			t.Errorf("AccurateSums %v: body %v, want %v", tt.Accurate, Body, tt.Want)
+		}
+	}
+}
+
+//=======================================================================================
+// On a well-conditioned model the two modes agree closely on every row, quadratic rows
+// included, since both go through ConBodyValue
+func TestAccurateSumsAgree(t *testing.T) {
+	Point := buildSparseModel(t, 200, 300, 8)
+	if SetQuadTerms(0, []QTERM{{Col1: 0, Col2: 1, Value: 2.0}, {Col1: 2, Col2: 2, Value: -0.5}}) > 0 {
+		t.Fatal("SetQuadTerms failed")
+	}
+	for i := 0; i < LP.NumRows; i++ {
+		setAccurateSums(t, false)
+		Plain, _ := ConBodyValue(i, Point)
+		setAccurateSums(t, true)
+		Accurate, _ := ConBodyValue(i, Point)
+		if math.Abs(Plain-Accurate) > 1.0e-12*(1.0+math.Abs(Accurate)) {
+			t.Errorf("row %s: plain body %v, compensated body %v", LP.Rows[i].Name, Plain, Accurate)
+		}
+	}
+}
+
+//=======================================================================================
+// Compare with BenchmarkConBodyValueCSR for the cost of the accuracy mode
+func BenchmarkConBodyValueAccurate(b *testing.B) {
+	Point := buildSparseModel(b, 5000, 10000, 20)
+	setAccurateSums(b, true)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < LP.NumRows; i++ {
+			Body, _ := ConBodyValue(i, Point)
+			benchSink = benchSink + Body
+		}
+	}
+}
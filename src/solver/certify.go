@@ -0,0 +1,162 @@
+package solver
+
+// Exact feasibility certification. Every row activity and bound is re-evaluated in rational
+// arithmetic from the exact decimal data in the MPS file (lp.KeepExact must be set before
+// reading), so the verdict does not depend on the summation order or on float64 rounding.
+// The point itself is taken exactly as the float64 values it holds. Quadratic terms are
+// evaluated exactly too, from the QCMATRIX data.
+
+import (
+	"fmt"
+	"lp"
+	"math/big"
+)
+
+// One violated row or bound, with its exact violation
+type EXACTVIOL struct {
+	Name      string
+	IsBound   bool // true: column bound, false: row
+	Violation *big.Rat
+}
+
+type CERTIFICATE struct {
+	Feasible    bool        // true if no row or bound is violated by more than the tolerance
+	Tol         *big.Rat    // Tolerance used
+	MaxViol     *big.Rat    // Exact maximum violation over rows and bounds (0 if none)
+	MaxViolName string      // Row or column with the maximum violation
+	NumViolated int         // Number of rows and bounds violated by more than Tol
+	Violated    []EXACTVIOL // The rows and bounds violated by more than Tol
+	NumInexact  int         // Number of MPS numbers that could not be read exactly
+}
+
+//=======================================================================================
+// Certifies the point against the exact model data within the absolute tolerance Tol.
+// Rows and bounds are checked on the same sides as GetViolation and TestPoint.
+// Status: 0(success), 1(no exact data: set lp.KeepExact before reading the model), 2(wrong point length)
+func CertifyPoint(Point []float64, Tol *big.Rat) (Cert CERTIFICATE, Status int) {
+
+	if len(lp.ExactEl) != len(lp.Element) || len(lp.ExactRows) != lp.LP.NumRows || len(lp.ExactCols) != lp.LP.NumCols {
+		fmt.Println("Error: no exact model data. Set lp.KeepExact before reading the MPS file.")
+		return Cert, 1
+	}
+	if len(Point) != lp.LP.NumCols {
+		fmt.Println("Error: point has", len(Point), "values but the model has", lp.LP.NumCols, "columns.")
+		return Cert, 2
+	}
+	Cert.Tol = Tol
+	Cert.MaxViol = new(big.Rat)
+	Cert.NumInexact = lp.NumInexact
+
+	// The point, exactly
+	X := make([]*big.Rat, len(Point))
+	for j := range Point {
+		X[j] = new(big.Rat)
+		if X[j].SetFloat64(Point[j]) == nil {
+			fmt.Println("Error: value of column", lp.LP.Cols[j].Name, "is not finite:", Point[j])
+			return Cert, 2
+		}
+	}
+
+	Record := func(Name string, IsBound bool, Viol *big.Rat) {
+		if Viol.Cmp(Cert.MaxViol) > 0 {
+			Cert.MaxViol.Set(Viol)
+			Cert.MaxViolName = Name
+		}
+		if Viol.Cmp(Tol) > 0 {
+			Cert.NumViolated++
+			Cert.Violated = append(Cert.Violated, EXACTVIOL{Name, IsBound, new(big.Rat).Set(Viol)})
+		}
+	}
+	Activity := new(big.Rat)
+	Term := new(big.Rat)
+	Viol := new(big.Rat)
+
+	// Rows
+	for i := 0; i < lp.LP.NumRows; i++ {
+		Row := &lp.LP.Rows[i]
+		if Row.Type == lp.RowN {
+			continue
+		}
+		Activity.SetInt64(0)
+		for _, iel := range Row.ElList {
+			Term.Mul(lp.ExactEl[iel], X[lp.Element[iel].Col])
+			Activity.Add(Activity, Term)
+		}
+		for k, QTerm := range Row.QTerms {
+			if Exact := lp.ExactQ[i]; k < len(Exact) {
+				Term.Set(Exact[k])
+			} else {
+				Term.SetFloat64(QTerm.Value)
+			}
+			Term.Mul(Term, X[QTerm.Col1])
+			Activity.Add(Activity, Term.Mul(Term, X[QTerm.Col2]))
+		}
+		Bnds := lp.ExactRows[i]
+		if Row.Type != lp.RowL && Bnds.Lo != nil && Activity.Cmp(Bnds.Lo) < 0 {
+			Record(Row.Name, false, Viol.Sub(Bnds.Lo, Activity))
+		}
+		if Row.Type != lp.RowG && Bnds.Up != nil && Activity.Cmp(Bnds.Up) > 0 {
+			Record(Row.Name, false, Viol.Sub(Activity, Bnds.Up))
+		}
+	}
+
+	// Bounds. GetStatistics swaps reversed float64 bounds, so do the same here. A semi-continuous
+	// or semi-integer column at exactly 0 satisfies its bounds.
+	for j := 0; j < lp.LP.NumCols; j++ {
+		if lp.LP.Cols[j].Type.IsSemi() && X[j].Sign() == 0 {
+			continue
+		}
+		Lo, Up := lp.ExactCols[j].Lo, lp.ExactCols[j].Up
+		if Lo != nil && Up != nil && Lo.Cmp(Up) > 0 {
+			Lo, Up = Up, Lo
+		}
+		if Lo != nil && X[j].Cmp(Lo) < 0 {
+			Record(lp.LP.Cols[j].Name, true, Viol.Sub(Lo, X[j]))
+		}
+		if Up != nil && X[j].Cmp(Up) > 0 {
+			Record(lp.LP.Cols[j].Name, true, Viol.Sub(X[j], Up))
+		}
+	}
+	Cert.Feasible = Cert.NumViolated == 0
+	return Cert, 0
+}
+
+//=======================================================================================
+// Prints a certificate: the verdict, the exact maximum violation and the violated rows and bounds
+func PrintCertificate(Cert CERTIFICATE) {
+
+	fmt.Println("Exact certification with tolerance", ratText(Cert.Tol))
+	if Cert.NumInexact > 0 {
+		fmt.Println("Warning:", Cert.NumInexact, "numbers in the MPS file could not be read exactly; their float64 values were used.")
+	}
+	if Cert.MaxViolName == "" {
+		fmt.Println("  Maximum violation: 0")
+	} else {
+		fmt.Println("  Maximum violation:", ratText(Cert.MaxViol), "at", Cert.MaxViolName)
+	}
+	if Cert.Feasible {
+		fmt.Println("  CERTIFIED FEASIBLE within the tolerance")
+		return
+	}
+	fmt.Println("  NOT feasible:", Cert.NumViolated, "rows/bounds violated by more than the tolerance")
+	for _, V := range Cert.Violated {
+		Kind := "row"
+		if V.IsBound {
+			Kind = "bound on"
+		}
+		fmt.Println("   ", Kind, V.Name, "violated by", ratText(V.Violation))
+	}
+}
+
+//=======================================================================================
+// A rational as an exact fraction when that is short, with its approximate decimal value
+func ratText(r *big.Rat) string {
+	f, _ := r.Float64()
+	if r.IsInt() {
+		return r.RatString()
+	}
+	if Exact := r.RatString(); len(Exact) <= 60 {
+		return fmt.Sprintf("%s (%.6g)", Exact, f)
+	}
+	return fmt.Sprintf("%s (rounded to 40 places)", r.FloatString(40))
+}
@@ -0,0 +1,133 @@
+package solver
+
+// Custom constraints given from Go code, Lo <= g(x) <= Up, alongside the rows of the model.
+// Constraint consensus only needs the value and the gradient of a constraint at a point: the
+// feasibility vector of a violated row is Violation * a / ||a||^2, and of a violated custom
+// constraint Violation * grad g(x) / ||grad g(x)||^2, its first-order step onto the constraint.
+// The custom constraints count in NINF and SFD wherever the rows do (CCSimple, GetSFD,
+// TestPoint, CountViolations), so the swarm, the sample boxes and the incumbents take them into
+// account without change. The simplex and the local search see the rows only.
+//
+// CC runs concurrently, so Value and Gradient may be called from several goroutines at once
+// and must not change shared state. The tolerance is applied to the value of g as for a row,
+// with the row-norm mode falling back to the absolute one as for quadratic rows.
+
+import (
+	"fmt"
+	"lp"
+	"math"
+)
+
+type Constraint interface {
+	Name() string
+	Bounds() (Lo float64, Up float64) // Either may be infinite (at or beyond plinfy in magnitude)
+	Value(Point []float64) float64
+	Gradient(Point []float64, Grad []float64) // Sets every element of Grad, which has one per column
+}
+
+// A Constraint made from functions. Without GradFunc the gradient is taken by central differences.
+type FUNCCONSTRAINT struct {
+	ConName   string
+	Lo, Up    float64
+	ValueFunc func(Point []float64) float64
+	GradFunc  func(Point []float64, Grad []float64)
+}
+
+var Constraints []Constraint // The custom constraints, added with AddConstraint
+
+var DiffStep float64 = 1.0e-6 // Relative step of the central differences in FUNCCONSTRAINT
+
+//=======================================================================================
+func (c *FUNCCONSTRAINT) Name() string                  { return c.ConName }
+func (c *FUNCCONSTRAINT) Bounds() (float64, float64)    { return c.Lo, c.Up }
+func (c *FUNCCONSTRAINT) Value(Point []float64) float64 { return c.ValueFunc(Point) }
+
+//=======================================================================================
+func (c *FUNCCONSTRAINT) Gradient(Point []float64, Grad []float64) {
+	if c.GradFunc != nil {
+		c.GradFunc(Point, Grad)
+		return
+	}
+	x := append([]float64(nil), Point...) // Point may be shared with other goroutines
+	for j := range x {
+		h := DiffStep * math.Max(1.0, math.Abs(Point[j]))
+		x[j] = Point[j] + h
+		Plus := c.ValueFunc(x)
+		x[j] = Point[j] - h
+		Minus := c.ValueFunc(x)
+		x[j] = Point[j]
+		Grad[j] = (Plus - Minus) / (2.0 * h)
+	}
+}
+
+//=======================================================================================
+// Adds a custom constraint for the following calls to Solve. Returns its index in Constraints.
+func AddConstraint(c Constraint) (ic int) {
+	Constraints = append(Constraints, c)
+	return len(Constraints) - 1
+}
+
+//=======================================================================================
+// Removes every custom constraint, e.g. before a different model is read in
+func ClearConstraints() {
+	Constraints = nil
+}
+
+//=======================================================================================
+// Violation of custom constraint ic at Point under the given tolerance mode, signed like
+// GetViolation (the bound minus the value), or 0 if it is satisfied. Value is g(Point).
+// Status: 0(success), 1(the value is NaN or infinite)
+func ConstraintViolation(ic int, Point []float64, Mode TOLMODE) (Status int, Violation float64, Value float64) {
+	c := Constraints[ic]
+	Value = c.Value(Point)
+	if math.IsNaN(Value) || math.IsInf(Value, 0) {
+		return 1, 0.0, Value
+	}
+	if Mode == TolRowNorm {
+		Mode = TolAbsolute
+	}
+	Lo, Up := c.Bounds()
+	if Lo > -plinfy && Value < Lo-BoundTolerance(Lo, Mode) {
+		return 0, Lo - Value, Value
+	}
+	if Up < plinfy && Value > Up+BoundTolerance(Up, Mode) {
+		return 0, Up - Value, Value
+	}
+	return 0, 0.0, Value
+}
+
+//=======================================================================================
+// The gradient of custom constraint ic at Point in Grad, and its squared length
+func constraintGradient(ic int, Point []float64, Grad []float64) (LenSq float64) {
+	Constraints[ic].Gradient(Point, Grad)
+	for _, g := range Grad {
+		LenSq += g * g
+	}
+	return LenSq
+}
+
+//=======================================================================================
+// Checks that the gradients of the custom constraints match central differences of their
+// values at Point, within a relative tolerance Tol, and prints the worst mismatches.
+// Returns the number of constraints whose gradient does not match.
+func CheckGradients(Point []float64, Tol float64) (NumBad int) {
+	Grad := make([]float64, lp.NumCols)
+	for ic, c := range Constraints {
+		Numeric := &FUNCCONSTRAINT{ValueFunc: c.Value}
+		NumGrad := make([]float64, lp.NumCols)
+		Numeric.Gradient(Point, NumGrad)
+		constraintGradient(ic, Point, Grad)
+		Worst, WorstCol := 0.0, -1
+		for j := range Grad {
+			if Diff := math.Abs(Grad[j]-NumGrad[j]) / math.Max(1.0, math.Abs(NumGrad[j])); Diff > Worst {
+				Worst, WorstCol = Diff, j
+			}
+		}
+		if Worst > Tol {
+			NumBad++
+			fmt.Println("Warning: gradient of constraint", c.Name(), "differs from central differences by", Worst,
+				"in column", lp.LP.Cols[WorstCol].Name, ":", Grad[WorstCol], "against", NumGrad[WorstCol])
+		}
+	}
+	return NumBad
+}
@@ -0,0 +1,520 @@
+package solver
+
+// Irreducible infeasible subset (IIS) analysis for models on which Solve fails. An IIS is a set
+// of rows and bounds that cannot be satisfied together, although every proper subset can: it
+// tells the modeller which constraints conflict.
+//
+// The candidates are ordered with the rows and bounds violated at the NINF incumbent first,
+// then the ones tight there, then the rest. The additive phase finds the shortest prefix of
+// that list that is infeasible (a doubling search followed by bisection), so its last member
+// belongs to every IIS inside it. The deletion filter then drops each other member whose
+// removal leaves the set infeasible. Every feasibility test is an LP check rather than a CC
+// run: phase 1 of the bounded primal simplex on the subset (see Simplex), in float64 with the
+// feasibility tolerance, so a subset violated by no more than featol counts as feasible.
+// Quadratic rows are left out of the candidates, since the simplex handles linear rows only.
+//
+// With the exact model data (lp.KeepExact), the result is then certified in rational
+// arithmetic: a Farkas certificate from the final phase 1 basis proves the members infeasible
+// together, and the simplex point that showed each member's removal feasible must satisfy the
+// other members exactly. An IIS that fails either test is reported as approximate.
+
+import (
+	"fmt"
+	"lp"
+	"math/big"
+	"time"
+)
+
+// Kinds of IIS members
+const (
+	IISRow   = iota // a row, with all its sides
+	IISLower        // the lower bound on a column
+	IISUpper        // the upper bound on a column
+)
+
+type IISMEMBER struct {
+	Kind  int    // IISRow, IISLower or IISUpper
+	Index int    // row or column number
+	Name  string // row or column name
+}
+
+type IISRESULT struct {
+	Members      []IISMEMBER // the irreducible infeasible subset
+	NumChecks    int         // number of LP feasibility checks used
+	NumStart     int         // number of candidates violated at the start point
+	NumQuadratic int         // number of quadratic rows, left out of the analysis
+	FilterTime   time.Duration
+	// Exact certification. 0(certified), 1(no exact model data), 2(infeasibility not proved),
+	// 3(irreducibility not proved)
+	ExactStatus int
+}
+
+var MaxIISExactRows int = 200 // Largest number of rows in an IIS that is certified in exact arithmetic
+
+//=======================================================================================
+func (m IISMEMBER) String() string {
+	switch m.Kind {
+	case IISLower:
+		return "lower bound on " + m.Name
+	case IISUpper:
+		return "upper bound on " + m.Name
+	}
+	return "row " + m.Name
+}
+
+//=======================================================================================
+// The bounds on the body of row icon implied by its type: G rows have no upper bound and
+// L rows no lower bound, whatever the reader left in RHSlo and RHSup.
+func RowBounds(icon int) (Lo float64, Up float64) {
+	Row := &lp.LP.Rows[icon]
+	Lo, Up = Row.RHSlo, Row.RHSup
+	switch Row.Type {
+	case lp.RowG:
+		Up = plinfy
+	case lp.RowL:
+		Lo = -plinfy
+	case lp.RowN:
+		Lo, Up = -plinfy, plinfy
+	}
+	return Lo, Up
+}
+
+//=======================================================================================
+// Finds an IIS of the current model, starting from the rows and bounds violated at Point
+// (normally the NINF incumbent NIncumbentPt left by Solve).
+// Status: 0(IIS found), 1(the model is feasible, so there is no IIS),
+// 2(a feasibility check failed: iteration limit or numerical trouble)
+func FindIIS(Point []float64) (Result IISRESULT, Status int) {
+
+	StartTime := time.Now()
+	Cands, NumViolated, NumQuadratic := iisCandidates(Point)
+	Result.NumStart = NumViolated
+	Result.NumQuadratic = NumQuadratic
+	var LastPoint []float64 // The simplex point of the last check that found its subset feasible
+	Check := func(Set []IISMEMBER) (Infeasible bool, Status int) {
+		Result.NumChecks++
+		Feasible, SimplexPt, Status := subsetFeasible(Set, Point)
+		if Feasible {
+			LastPoint = SimplexPt
+		}
+		return !Feasible, Status
+	}
+
+	// Additive phase: the shortest infeasible prefix of the candidate list. Doubling search
+	// for an infeasible prefix length, starting from the violated candidates.
+	Lo := 0               // prefixes of length Lo are known to be feasible
+	var LoPoint []float64 // a point satisfying the prefix of length Lo (nil for the empty prefix)
+	Hi := Result.NumStart
+	if Hi < 1 {
+		Hi = 1
+	}
+	for {
+		if Hi > len(Cands) {
+			Hi = len(Cands)
+		}
+		Infeasible, CheckStatus := Check(Cands[:Hi])
+		if CheckStatus > 0 {
+			return Result, 2
+		}
+		if Infeasible {
+			break
+		}
+		if Hi == len(Cands) {
+			Result.FilterTime = time.Since(StartTime)
+			return Result, 1
+		}
+		Lo, LoPoint = Hi, LastPoint
+		Hi = 2 * Hi
+	}
+	// Bisection for the shortest infeasible prefix
+	for Hi-Lo > 1 {
+		Mid := (Lo + Hi) / 2
+		Infeasible, CheckStatus := Check(Cands[:Mid])
+		if CheckStatus > 0 {
+			return Result, 2
+		}
+		if Infeasible {
+			Hi = Mid
+		} else {
+			Lo, LoPoint = Mid, LastPoint
+		}
+	}
+	Set := append([]IISMEMBER(nil), Cands[:Hi]...)
+
+	// Deletion filter. The last member of the prefix is in every IIS of the set, so it stays.
+	// Witness holds, for each member kept, a point satisfying the set without it.
+	Witness := map[IISMEMBER][]float64{Set[len(Set)-1]: LoPoint}
+	Trial := make([]IISMEMBER, 0, len(Set))
+	for k := len(Set) - 2; k >= 0; k-- {
+		Trial = append(Trial[:0], Set[:k]...)
+		Trial = append(Trial, Set[k+1:]...)
+		Infeasible, CheckStatus := Check(Trial)
+		if CheckStatus > 0 {
+			return Result, 2
+		}
+		if Infeasible {
+			Set = append(Set[:k], Set[k+1:]...)
+		} else {
+			Witness[Set[k]] = LastPoint
+		}
+	}
+	Result.Members = Set
+	Result.FilterTime = time.Since(StartTime)
+	Result.ExactStatus = certifyIIS(Set, Witness, Point)
+	return Result, 0
+}
+
+//=======================================================================================
+// Prints the members of an IIS by name, with their data
+func PrintIIS(Result IISRESULT) {
+
+	fmt.Println("Irreducible infeasible subset:", len(Result.Members), "members (", Result.NumChecks,
+		"LP feasibility checks,", Result.NumStart, "candidates violated at the start point,", Result.FilterTime.Seconds(), "s )")
+	if Result.NumQuadratic > 0 {
+		fmt.Println("  ", Result.NumQuadratic, "quadratic rows were left out: the LP checks handle linear rows only")
+	}
+	switch Result.ExactStatus {
+	case 0:
+		fmt.Println("   Certified in exact arithmetic: the members are infeasible together and every proper subset is feasible")
+	case 1:
+		fmt.Println("   Approximate (float64 checks within featol): there is no exact model data to certify it with")
+	case 2:
+		fmt.Println("   Approximate (float64 checks within featol): the infeasibility could not be proved in exact arithmetic")
+	case 3:
+		fmt.Println("   Approximate (float64 checks within featol): the irreducibility could not be proved in exact arithmetic")
+	}
+	for _, m := range Result.Members {
+		switch m.Kind {
+		case IISRow:
+			Lo, Up := RowBounds(m.Index)
+			fmt.Printf("  row    %-16s %s  bounds [%s, %s]  %d elements\n", m.Name, lp.LP.Rows[m.Index].Type,
+				boundText(finiteOrNil(Lo), "-inf"), boundText(finiteOrNil(Up), "inf"), lp.LP.Rows[m.Index].NumEl)
+		case IISLower:
+			fmt.Printf("  bound  %-16s >= %.12g\n", m.Name, lp.LP.Cols[m.Index].BndLo)
+		case IISUpper:
+			fmt.Printf("  bound  %-16s <= %.12g\n", m.Name, lp.LP.Cols[m.Index].BndUp)
+		}
+	}
+}
+
+//=======================================================================================
+// All linear rows (except nonbinding ones) and finite bounds, ordered: violated at Point, then
+// tight at Point, then the rest. NumViolated is the number violated at Point, NumQuadratic the
+// number of quadratic rows left out.
+func iisCandidates(Point []float64) (Cands []IISMEMBER, NumViolated int, NumQuadratic int) {
+
+	var Violated, Tight, Rest []IISMEMBER
+
+	for icon := 0; icon < lp.NumRows; icon++ {
+		if lp.LP.Rows[icon].Type == lp.RowN {
+			continue
+		}
+		if len(lp.LP.Rows[icon].QTerms) > 0 {
+			NumQuadratic++
+			continue
+		}
+		m := IISMEMBER{IISRow, icon, lp.LP.Rows[icon].Name}
+		FVStatus, ViolStatus, _ := GetViolation(icon, Point)
+		switch {
+		case FVStatus > 0 || ViolStatus == 0:
+			Violated = append(Violated, m)
+		case ViolStatus == 2:
+			Tight = append(Tight, m)
+		default:
+			Rest = append(Rest, m)
+		}
+	}
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		Col := &lp.LP.Cols[ivar]
+		Viol := BoundViolation(ivar, Point[ivar])
+		if Col.BndLo > -plinfy {
+			m := IISMEMBER{IISLower, ivar, Col.Name}
+			switch {
+			case Viol > 0.0:
+				Violated = append(Violated, m)
+			case Point[ivar]-Col.BndLo <= BoundTolerance(Col.BndLo, TolMode):
+				Tight = append(Tight, m)
+			default:
+				Rest = append(Rest, m)
+			}
+		}
+		if Col.BndUp < plinfy {
+			m := IISMEMBER{IISUpper, ivar, Col.Name}
+			switch {
+			case Viol < 0.0:
+				Violated = append(Violated, m)
+			case Col.BndUp-Point[ivar] <= BoundTolerance(Col.BndUp, TolMode):
+				Tight = append(Tight, m)
+			default:
+				Rest = append(Rest, m)
+			}
+		}
+	}
+	Cands = append(Violated, Tight...)
+	return append(Cands, Rest...), len(Violated), NumQuadratic
+}
+
+//=======================================================================================
+// LP feasibility check of a subset of the rows and bounds: phase 1 of the bounded primal
+// simplex, warm-started from Start. Columns whose bounds are not in the subset are free.
+// Point is the simplex point if the subset is feasible.
+// Status: 0(success), 1(iteration limit or numerical trouble)
+func subsetFeasible(Set []IISMEMBER, Start []float64) (Feasible bool, Point []float64, Status int) {
+
+	Result, Status := Simplex(subsetOptions(Set, Start))
+	switch Status {
+	case 0:
+		return true, Result.Point, 0
+	case 1:
+		return false, nil, 0
+	}
+	return false, nil, 1
+}
+
+//=======================================================================================
+// Simplex options that include just the rows and bounds in Set
+func subsetOptions(Set []IISMEMBER, Start []float64) SIMPLEXOPTS {
+
+	Opts := SIMPLEXOPTS{Start: Start}
+	Opts.Rows = make([]bool, lp.NumRows)
+	Opts.UseLo = make([]bool, lp.NumCols)
+	Opts.UseUp = make([]bool, lp.NumCols)
+	for _, m := range Set {
+		switch m.Kind {
+		case IISRow:
+			Opts.Rows[m.Index] = true
+		case IISLower:
+			Opts.UseLo[m.Index] = true
+		case IISUpper:
+			Opts.UseUp[m.Index] = true
+		}
+	}
+	return Opts
+}
+
+//=======================================================================================
+// Certifies an IIS in rational arithmetic from the exact model data: the members must be
+// infeasible together (see exactInfeasible), and the witness point of each member must
+// satisfy all the other members exactly.
+// Status: 0(certified), 1(no exact model data), 2(infeasibility not proved), 3(irreducibility not proved)
+func certifyIIS(Set []IISMEMBER, Witness map[IISMEMBER][]float64, Start []float64) (Status int) {
+
+	if len(lp.ExactEl) != len(lp.Element) || len(lp.ExactRows) != lp.LP.NumRows || len(lp.ExactCols) != lp.LP.NumCols {
+		return 1
+	}
+	if !exactInfeasible(Set, Start) {
+		return 2
+	}
+	Rest := make([]IISMEMBER, 0, len(Set))
+	for k, m := range Set {
+		Rest = append(append(Rest[:0], Set[:k]...), Set[k+1:]...)
+		if len(Rest) > 0 && !exactSatisfies(Rest, Witness[m]) {
+			return 3
+		}
+	}
+	return 0
+}
+
+//=======================================================================================
+// Proves in exact arithmetic that the members of Set can't be satisfied together. Phase 1 of
+// the simplex on them ends with a basis whose duals y combine the rows into c.x - y.s = 0,
+// where c = y'A and s holds the row activities. With y solved for exactly from that basis, the
+// set is infeasible if the range of c.x - y.s over the members' bounds does not include 0.
+func exactInfeasible(Set []IISMEMBER, Start []float64) bool {
+
+	Opts := subsetOptions(Set, Start)
+	Res, Status := Simplex(Opts)
+	m, n := len(Res.RowList), lp.NumCols
+	if Status != 1 || m == 0 || m > MaxIISExactRows {
+		return false
+	}
+	RowMap := make([]int, lp.NumRows) // Simplex row of each model row, or -1
+	for i := range RowMap {
+		RowMap[i] = -1
+	}
+	for r, i := range Res.RowList {
+		RowMap[i] = r
+	}
+
+	// B'y = Phase1Cost, with the exact elements
+	M := make([][]*big.Rat, m)
+	for k, v := range Res.Basis {
+		M[k] = make([]*big.Rat, m+1)
+		for r := range M[k] {
+			M[k][r] = new(big.Rat)
+		}
+		if v < n {
+			for _, iel := range lp.LP.Cols[v].ElList {
+				if r := RowMap[lp.Element[iel].Row]; r >= 0 {
+					M[k][r].Add(M[k][r], lp.ExactEl[iel])
+				}
+			}
+		} else {
+			M[k][v-n].SetInt64(-1) // the logical's column is -e
+		}
+		M[k][m].SetFloat64(Res.Phase1Cost[k])
+	}
+	y := solveRat(M)
+	if y == nil {
+		return false
+	}
+
+	// The range [Min, Max] of c.x - y.s, with a flag for each infinite end
+	Min, Max := new(big.Rat), new(big.Rat)
+	var MinInf, MaxInf bool
+	Term := new(big.Rat)
+	AddRange := func(Coef *big.Rat, Lo *big.Rat, Up *big.Rat) {
+		if Coef.Sign() < 0 {
+			Lo, Up = Up, Lo
+		}
+		if Lo == nil {
+			MinInf = true
+		} else {
+			Min.Add(Min, Term.Mul(Coef, Lo))
+		}
+		if Up == nil {
+			MaxInf = true
+		} else {
+			Max.Add(Max, Term.Mul(Coef, Up))
+		}
+	}
+	c := make([]*big.Rat, n)
+	for r, i := range Res.RowList {
+		if y[r].Sign() == 0 {
+			continue
+		}
+		for _, iel := range lp.LP.Rows[i].ElList {
+			j := lp.Element[iel].Col
+			if c[j] == nil {
+				c[j] = new(big.Rat)
+			}
+			c[j].Add(c[j], Term.Mul(y[r], lp.ExactEl[iel]))
+		}
+		Lo, Up := exactRowBounds(i)
+		AddRange(new(big.Rat).Neg(y[r]), Lo, Up)
+	}
+	for j := 0; j < n; j++ {
+		if c[j] == nil || c[j].Sign() == 0 {
+			continue
+		}
+		Lo, Up := exactHullBounds(j)
+		if !Opts.UseLo[j] {
+			Lo = nil
+		}
+		if !Opts.UseUp[j] {
+			Up = nil
+		}
+		AddRange(c[j], Lo, Up)
+	}
+	return (!MaxInf && Max.Sign() < 0) || (!MinInf && Min.Sign() > 0)
+}
+
+//=======================================================================================
+// true if Point satisfies every member of Set exactly, on the exact model data
+func exactSatisfies(Set []IISMEMBER, Point []float64) bool {
+
+	if Point == nil {
+		return false
+	}
+	X := make([]*big.Rat, len(Point))
+	for j := range Point {
+		if X[j] = new(big.Rat).SetFloat64(Point[j]); X[j] == nil {
+			return false
+		}
+	}
+	Activity := new(big.Rat)
+	Term := new(big.Rat)
+	for _, m := range Set {
+		var Value, Lo, Up *big.Rat
+		switch m.Kind {
+		case IISRow:
+			Activity.SetInt64(0)
+			for _, iel := range lp.LP.Rows[m.Index].ElList {
+				Activity.Add(Activity, Term.Mul(lp.ExactEl[iel], X[lp.Element[iel].Col]))
+			}
+			Value = Activity
+			Lo, Up = exactRowBounds(m.Index)
+		case IISLower:
+			Value = X[m.Index]
+			Lo, _ = exactHullBounds(m.Index)
+		case IISUpper:
+			Value = X[m.Index]
+			_, Up = exactHullBounds(m.Index)
+		}
+		if (Lo != nil && Value.Cmp(Lo) < 0) || (Up != nil && Value.Cmp(Up) > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+//=======================================================================================
+// The exact bounds on the body of row icon, as RowBounds gives them. nil: infinite.
+func exactRowBounds(icon int) (Lo *big.Rat, Up *big.Rat) {
+	Lo, Up = lp.ExactRows[icon].Lo, lp.ExactRows[icon].Up
+	switch lp.LP.Rows[icon].Type {
+	case lp.RowG:
+		Up = nil
+	case lp.RowL:
+		Lo = nil
+	case lp.RowN:
+		Lo, Up = nil, nil
+	}
+	return Lo, Up
+}
+
+//=======================================================================================
+// The exact bounds of the convex hull of the domain of column ivar, as HullBounds gives them.
+// nil: infinite.
+func exactHullBounds(ivar int) (Lo *big.Rat, Up *big.Rat) {
+	Lo, Up = lp.ExactCols[ivar].Lo, lp.ExactCols[ivar].Up
+	if Lo != nil && Up != nil && Lo.Cmp(Up) > 0 {
+		Lo, Up = Up, Lo // GetStatistics swaps reversed float64 bounds too
+	}
+	if lp.LP.Cols[ivar].Type.IsSemi() {
+		if Lo != nil && Lo.Sign() > 0 {
+			Lo = new(big.Rat)
+		}
+		if Up != nil && Up.Sign() < 0 {
+			Up = new(big.Rat)
+		}
+	}
+	return Lo, Up
+}
+
+//=======================================================================================
+// Solves the square system whose augmented rows are M (the right hand side last) by
+// Gauss-Jordan elimination in rationals. M is overwritten. Returns nil if it is singular.
+func solveRat(M [][]*big.Rat) []*big.Rat {
+
+	m := len(M)
+	Factor := new(big.Rat)
+	Term := new(big.Rat)
+	for col := 0; col < m; col++ {
+		Piv := -1
+		for k := col; k < m; k++ {
+			if M[k][col].Sign() != 0 {
+				Piv = k
+				break
+			}
+		}
+		if Piv < 0 {
+			return nil
+		}
+		M[col], M[Piv] = M[Piv], M[col]
+		for k := 0; k < m; k++ {
+			if k == col || M[k][col].Sign() == 0 {
+				continue
+			}
+			Factor.Quo(M[k][col], M[col][col])
+			for c := col; c <= m; c++ {
+				M[k][c].Sub(M[k][c], Term.Mul(Factor, M[col][c]))
+			}
+		}
+	}
+	x := make([]*big.Rat, m)
+	for k := range x {
+		x[k] = new(big.Rat).Quo(M[k][m], M[k][k])
+	}
+	return x
+}
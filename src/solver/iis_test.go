@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"lp"
+	"sort"
+	"testing"
+)
+
+//=======================================================================================
+// Builds C1: x + y >= 4, C2: x <= 1, C3: y <= 2 and FREE: x - y <= 5 over free x and y, so
+// the only IIS is {C1, C2, C3}. With Quadratic, the row Q: x + x*y <= 10 is added too.
+func buildIISModel(t *testing.T, Exact bool, Quadratic bool) {
+	t.Helper()
+	Save := lp.KeepExact
+	lp.KeepExact = Exact
+	t.Cleanup(func() { lp.KeepExact = Save })
+
+	lp.NewModel("IISTEST", 1.0e10, 1.0e-6)
+	x, _ := lp.AddColumn("X", lp.ColR, -1.0e10, 1.0e10)
+	y, _ := lp.AddColumn("Y", lp.ColR, -1.0e10, 1.0e10)
+	type iisRow struct {
+		Name string
+		Type lp.ROWTYPE
+		RHS  float64
+		Vals []float64 // coefficients of x and y
+	}
+	Rows := []iisRow{
+		{"C1", lp.RowG, 4.0, []float64{1.0, 1.0}},
+		{"C2", lp.RowL, 1.0, []float64{1.0, 0.0}},
+		{"C3", lp.RowL, 2.0, []float64{0.0, 1.0}},
+		{"FREE", lp.RowL, 5.0, []float64{1.0, -1.0}},
+	}
+	if Quadratic {
+		Rows = append(Rows, iisRow{"Q", lp.RowL, 10.0, []float64{1.0, 0.0}})
+	}
+	for _, Row := range Rows {
+		irow, _ := lp.AddRow(Row.Name, Row.Type, Row.RHS, 0.0)
+		for k, icol := range []int{x, y} {
+			if Row.Vals[k] != 0.0 {
+				lp.SetCoefficient(irow, icol, Row.Vals[k])
+			}
+		}
+	}
+	if lp.EndModel() > 0 {
+		t.Fatal("EndModel failed")
+	}
+	if Quadratic {
+		Q, _ := lp.RowNumber("Q")
+		lp.SetQuadTerms(Q, []lp.QTERM{{Col1: x, Col2: y, Value: 1.0}})
+	}
+	SetTolerances(1.0e10, 1.0e-6)
+	PrintLevel = 0
+}
+
+//=======================================================================================
+func TestFindIIS(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Exact         bool
+		Quadratic     bool
+		WantExact     int // ExactStatus
+		WantQuadratic int
+	}{
+		{"certified", true, false, 0, 0},
+		{"no exact data", false, false, 1, 0},
+		{"quadratic row left out", true, true, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			buildIISModel(t, tt.Exact, tt.Quadratic)
+			Result, Status := FindIIS(make([]float64, lp.NumCols))
+			if Status != 0 {
+				t.Fatalf("status %d, want 0 (IIS found)", Status)
+			}
+			var Names []string
+			for _, m := range Result.Members {
+				Names = append(Names, m.Name)
+			}
+			sort.Strings(Names)
+			if len(Names) != 3 || Names[0] != "C1" || Names[1] != "C2" || Names[2] != "C3" {
+				t.Errorf("members %v, want [C1 C2 C3]", Names)
+			}
+			if Result.ExactStatus != tt.WantExact {
+				t.Errorf("exact status %d, want %d", Result.ExactStatus, tt.WantExact)
+			}
+			if Result.NumQuadratic != tt.WantQuadratic {
+				t.Errorf("%d quadratic rows left out, want %d", Result.NumQuadratic, tt.WantQuadratic)
+			}
+		})
+	}
+}
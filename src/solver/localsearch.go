@@ -0,0 +1,244 @@
+package solver
+
+// Local search on the integer columns, for MIP points that rounding has left with a few
+// violated rows. WalkSAT style: each move picks a violated row at random and moves one of its
+// integer columns, either a flip (binary columns) or a shift that satisfies the row or takes
+// one step towards it. The move that most reduces NINF, ties broken by SFD, is made, except
+// that with probability LSNoise a random one is made instead, and a column that has moved may
+// not move again for LSTabuTenure moves unless that gives a new best NINF. Moves are made even
+// when no move improves, so that the search can leave a local minimum; the best point seen is
+// kept. The row activities are updated through the moved column's element list only, which
+// is why Solve does not run the search on models with quadratic rows.
+
+import (
+	"lp"
+	"math"
+	"math/rand"
+)
+
+var LocalSearch bool      // Run the local search after each round of Solve on models with integer columns
+var LSMaxMoves int = 1000 // Maximum number of moves per local search
+var LSTabuTenure int = 10 // Number of moves for which a column that moved may not move again
+var LSNoise float64 = 0.1 // Probability of a random move instead of the best one
+
+type LSRESULT struct {
+	Point     []float64 // Best point found
+	NINF      int       // NINF at the best point
+	SFD       float64   // SFD at the best point
+	StartNINF int       // NINF at the start point after rounding
+	NumMoves  int       // Number of moves made
+}
+
+// The state of the search: the point, the row activities and the violated rows
+type lsState struct {
+	Point     []float64
+	Activity  []float64
+	Violation []float64 // Signed as in GetViolation, 0 if the row is satisfied
+	NINF      int
+	SFD       float64
+	Violated  []int // The violated rows
+	Position  []int // Position of each row in Violated, or -1
+}
+
+// A candidate move: column ivar to Value, and the changes in NINF and SFD it makes
+type lsMove struct {
+	ivar  int
+	Value float64
+	DNINF int
+	DSFD  float64
+}
+
+//=======================================================================================
+// Local search from Start, whose integer columns are first rounded to the nearest integers.
+// Status: 0(feasible point found), 1(NINF reduced), 2(no improvement on the rounded start)
+func LocalSearchPoint(Start []float64, RandNum *rand.Rand) (Result LSRESULT, Status int) {
+
+	var s lsState
+	s.Point = append([]float64(nil), Start...)
+	RoundPoint(s.Point, RoundSimple, nil)
+	s.init()
+	Result.StartNINF = s.NINF
+	Result.Point = append([]float64(nil), s.Point...)
+	Result.NINF, Result.SFD = s.NINF, s.SFD
+
+	TabuUntil := make([]int, lp.NumCols)
+	var Moves []lsMove
+	for Move := 1; Move <= LSMaxMoves && s.NINF > 0; Move++ {
+		// A violated row with at least one integer column that can move. Rows without one
+		// are tried a limited number of times before the search gives up.
+		if len(s.Violated) == 0 {
+			break // only column violations are left, which the moves can't repair
+		}
+		Moves = Moves[:0]
+		for Try := 0; Try < 10 && len(Moves) == 0; Try++ {
+			Moves = s.candidates(s.Violated[RandNum.Intn(len(s.Violated))], Moves)
+		}
+		if len(Moves) == 0 {
+			break
+		}
+
+		var Chosen *lsMove
+		if RandNum.Float64() < LSNoise {
+			Chosen = &Moves[RandNum.Intn(len(Moves))]
+		} else {
+			for k := range Moves {
+				m := &Moves[k]
+				Tabu := TabuUntil[m.ivar] >= Move && s.NINF+m.DNINF >= Result.NINF
+				if Tabu {
+					continue
+				}
+				if Chosen == nil || m.DNINF < Chosen.DNINF || (m.DNINF == Chosen.DNINF && m.DSFD < Chosen.DSFD) {
+					Chosen = m
+				}
+			}
+			if Chosen == nil {
+				// Every candidate is tabu: make a random move
+				Chosen = &Moves[RandNum.Intn(len(Moves))]
+			}
+		}
+		s.apply(Chosen.ivar, Chosen.Value)
+		TabuUntil[Chosen.ivar] = Move + LSTabuTenure
+		Result.NumMoves = Move
+		if s.NINF < Result.NINF || (s.NINF == Result.NINF && s.SFD < Result.SFD) {
+			copy(Result.Point, s.Point)
+			Result.NINF, Result.SFD = s.NINF, s.SFD
+		}
+	}
+
+	switch {
+	case Result.NINF == 0:
+		return Result, 0
+	case Result.NINF < Result.StartNINF:
+		return Result, 1
+	}
+	return Result, 2
+}
+
+//=======================================================================================
+// Sets up the activities, violations, NINF and SFD at s.Point. The moves never change the
+// continuous columns, so they are first moved into their domains here. The bound and
+// integrality violations of the columns are counted once here: the moves keep the integer
+// columns at integer values within their bounds.
+func (s *lsState) init() {
+
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		if !lp.LP.Cols[ivar].Type.IsInteger() {
+			Lo, Up := HullBounds(ivar)
+			s.Point[ivar] = SnapSemi(ivar, math.Min(math.Max(s.Point[ivar], Lo), Up))
+		}
+	}
+	s.Activity = make([]float64, lp.NumRows)
+	s.Violation = make([]float64, lp.NumRows)
+	s.Position = make([]int, lp.NumRows)
+	for icon := 0; icon < lp.NumRows; icon++ {
+		for k := lp.LP.RowStart[icon]; k < lp.LP.RowStart[icon+1]; k++ {
+			s.Activity[icon] += lp.LP.RowVal[k] * s.Point[lp.LP.ColIdx[k]]
+		}
+		s.Position[icon] = -1
+		s.setRow(icon, s.Activity[icon])
+	}
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		for _, Violation := range []float64{BoundViolation(ivar, s.Point[ivar]), IntViolation(ivar, s.Point[ivar])} {
+			if Violation != 0.0 {
+				s.NINF++
+				s.SFD += math.Abs(Violation)
+			}
+		}
+	}
+}
+
+//=======================================================================================
+// Violation of row icon at activity Act, with the tolerances of the feasibility oracle
+func lsViolation(icon int, Act float64) float64 {
+	Lo, Up := RowBounds(icon)
+	if Lo > -plinfy && Act < Lo-RowTolerance(icon, Lo, TolMode) {
+		return Lo - Act
+	}
+	if Up < plinfy && Act > Up+RowTolerance(icon, Up, TolMode) {
+		return Up - Act
+	}
+	return 0.0
+}
+
+//=======================================================================================
+// Sets the activity of row icon and brings its violation, NINF, SFD and the violated list up to date
+func (s *lsState) setRow(icon int, Act float64) {
+
+	Old := s.Violation[icon]
+	New := lsViolation(icon, Act)
+	s.Activity[icon] = Act
+	s.Violation[icon] = New
+	s.SFD += (math.Abs(New) - math.Abs(Old)) * lp.LP.Rows[icon].InvGradVecLen
+	switch {
+	case Old == 0.0 && New != 0.0:
+		s.NINF++
+		s.Position[icon] = len(s.Violated)
+		s.Violated = append(s.Violated, icon)
+	case Old != 0.0 && New == 0.0:
+		s.NINF--
+		Last := s.Violated[len(s.Violated)-1]
+		s.Violated[s.Position[icon]] = Last
+		s.Position[Last] = s.Position[icon]
+		s.Violated = s.Violated[:len(s.Violated)-1]
+		s.Position[icon] = -1
+	}
+}
+
+//=======================================================================================
+// Appends the moves of the integer columns of violated row icon to Moves, with their effects
+func (s *lsState) candidates(icon int, Moves []lsMove) []lsMove {
+
+	for k := lp.LP.RowStart[icon]; k < lp.LP.RowStart[icon+1]; k++ {
+		ivar := lp.LP.ColIdx[k]
+		if !lp.LP.Cols[ivar].Type.IsInteger() || lp.LP.RowVal[k] == 0.0 {
+			continue
+		}
+		x := s.Point[ivar]
+		Shift := s.Violation[icon] / lp.LP.RowVal[k] // the shift that would satisfy the row exactly
+		Step := math.Copysign(1.0, Shift)
+		Targets := []float64{x + Step, x + Step*math.Ceil(math.Abs(Shift)-featol)}
+		if lp.LP.Cols[ivar].Type.IsSemi() {
+			Targets = append(Targets, 0.0) // switching a semi-integer column off
+		}
+		for t, Value := range Targets {
+			Value = intInBounds(ivar, Value)
+			if Value == x || (t > 0 && Value == intInBounds(ivar, Targets[t-1])) {
+				continue
+			}
+			DNINF, DSFD := s.effect(ivar, Value)
+			Moves = append(Moves, lsMove{ivar, Value, DNINF, DSFD})
+		}
+	}
+	return Moves
+}
+
+//=======================================================================================
+// Changes in NINF and SFD if column ivar moves to Value
+func (s *lsState) effect(ivar int, Value float64) (DNINF int, DSFD float64) {
+
+	Delta := Value - s.Point[ivar]
+	for _, iel := range lp.LP.Cols[ivar].ElList {
+		El := &lp.Element[iel]
+		Old := s.Violation[El.Row]
+		New := lsViolation(El.Row, s.Activity[El.Row]+El.Value*Delta)
+		if Old == 0.0 && New != 0.0 {
+			DNINF++
+		} else if Old != 0.0 && New == 0.0 {
+			DNINF--
+		}
+		DSFD += (math.Abs(New) - math.Abs(Old)) * lp.LP.Rows[El.Row].InvGradVecLen
+	}
+	return DNINF, DSFD
+}
+
+//=======================================================================================
+// Moves column ivar to Value, updating only the rows it appears in
+func (s *lsState) apply(ivar int, Value float64) {
+
+	Delta := Value - s.Point[ivar]
+	s.Point[ivar] = Value
+	for _, iel := range lp.LP.Cols[ivar].ElList {
+		El := &lp.Element[iel]
+		s.setRow(El.Row, s.Activity[El.Row]+El.Value*Delta)
+	}
+}
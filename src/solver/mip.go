@@ -0,0 +1,230 @@
+package solver
+
+// The MIP feasibility mode. With MIPMode set on a model that has integer columns, CC treats
+// integrality as one more constraint: an integer column more than featol from the nearest
+// integer counts in NINF and SFD, and its feasibility vector points at that integer. Each CC
+// run alternates rounding with CC repair in the style of a feasibility pump: the integer
+// columns are rounded every RoundEvery iterations and CC then moves the point back towards
+// the rows. When a rounding repeats the previous one the pump is cycling, and the point is
+// re-rounded randomly instead.
+//
+// Rounding modes:
+//   simple:  each integer column to the nearest integer
+//   random:  up with probability equal to the fractional part, down otherwise
+//   ordered: the columns one at a time, nearly integral ones first, each in the direction
+//            that leaves the smaller total violation in its rows given the columns already done
+//            (the linear part of the rows only)
+
+import (
+	"fmt"
+	"lp"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+type ROUNDMODE int
+
+const (
+	RoundSimple ROUNDMODE = iota
+	RoundRandom
+	RoundOrdered
+)
+
+var RoundModes = []ROUNDMODE{RoundSimple, RoundRandom, RoundOrdered}
+
+var MIPMode bool        // Enforce integrality on the integer columns
+var RoundMode ROUNDMODE // Rounding used in the MIP mode. The zero value is simple rounding.
+var RoundEvery int = 5  // CC iterations between roundings in the MIP mode
+var MIPCCIts int = 30   // CC iterations per run in the MIP mode (10 otherwise)
+
+// Integrality of the integer columns at a point, whether or not the MIP mode is on
+type FRACTIONALITY struct {
+	NumInt  int      // Number of integer columns
+	NumFrac int      // Number more than featol from the nearest integer
+	SumFrac float64  // Total distance to the nearest integer over those columns
+	MaxFrac float64  // Largest distance to the nearest integer
+	Columns []string // Names of the fractional columns
+}
+
+//=======================================================================================
+func (m ROUNDMODE) String() string {
+	switch m {
+	case RoundRandom:
+		return "random"
+	case RoundOrdered:
+		return "ordered"
+	}
+	return "simple"
+}
+
+//=======================================================================================
+// Found is false if Name is not a rounding mode
+func ParseRoundMode(Name string) (Mode ROUNDMODE, Found bool) {
+	for _, Mode = range RoundModes {
+		if Mode.String() == Name {
+			return Mode, true
+		}
+	}
+	return RoundSimple, false
+}
+
+//=======================================================================================
+// Makes *ROUNDMODE a flag.Value, so a rounding mode can be set from the command line
+func (m *ROUNDMODE) Set(Name string) error {
+	Mode, Found := ParseRoundMode(Name)
+	if !Found {
+		return fmt.Errorf("unknown rounding mode %q: use simple, random or ordered", Name)
+	}
+	*m = Mode
+	return nil
+}
+
+//=======================================================================================
+// True if integrality is enforced: the MIP mode is on and the model has integer columns
+func mipActive() bool {
+	return MIPMode && lp.NumICols > 0
+}
+
+//=======================================================================================
+// Measures how far the integer columns of Point are from integer values
+func GetFractionality(Point []float64) (Frac FRACTIONALITY) {
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		if !lp.LP.Cols[ivar].Type.IsInteger() {
+			continue
+		}
+		Frac.NumInt++
+		Distance := math.Abs(Point[ivar] - math.Floor(Point[ivar]+0.5))
+		if Distance <= featol {
+			continue
+		}
+		Frac.NumFrac++
+		Frac.SumFrac += Distance
+		Frac.MaxFrac = math.Max(Frac.MaxFrac, Distance)
+		Frac.Columns = append(Frac.Columns, lp.LP.Cols[ivar].Name)
+	}
+	return Frac
+}
+
+//=======================================================================================
+// Rounds the integer columns of Point in place. RandNum is used by the random mode only.
+// Returns the number of columns whose value changed.
+func RoundPoint(Point []float64, Mode ROUNDMODE, RandNum *rand.Rand) (NumChanged int) {
+
+	if Mode == RoundOrdered {
+		return roundOrdered(Point)
+	}
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		if !lp.LP.Cols[ivar].Type.IsInteger() {
+			continue
+		}
+		Value := math.Floor(Point[ivar] + 0.5)
+		if Mode == RoundRandom {
+			Value = math.Floor(Point[ivar])
+			if RandNum.Float64() < Point[ivar]-Value {
+				Value++
+			}
+		}
+		Value = intInBounds(ivar, Value)
+		if Value != Point[ivar] {
+			Point[ivar] = Value
+			NumChanged++
+		}
+	}
+	return NumChanged
+}
+
+//=======================================================================================
+// Variable-ordered rounding. The row activities are kept up to date through the CSC copy of
+// the matrix as each column is rounded.
+func roundOrdered(Point []float64) (NumChanged int) {
+
+	Activity := make([]float64, lp.NumRows)
+	for icon := 0; icon < lp.NumRows; icon++ {
+		for k := lp.LP.RowStart[icon]; k < lp.LP.RowStart[icon+1]; k++ {
+			Activity[icon] += lp.LP.RowVal[k] * Point[lp.LP.ColIdx[k]]
+		}
+	}
+	var Order []int
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		if lp.LP.Cols[ivar].Type.IsInteger() {
+			Order = append(Order, ivar)
+		}
+	}
+	Fractionality := func(x float64) float64 { return math.Abs(x - math.Floor(x+0.5)) }
+	sort.SliceStable(Order, func(a, b int) bool {
+		return Fractionality(Point[Order[a]]) < Fractionality(Point[Order[b]])
+	})
+
+	// Total violation of the rows of column ivar if it moves by Delta
+	RowViolation := func(ivar int, Delta float64) (Sum float64) {
+		for k := lp.LP.ColStart[ivar]; k < lp.LP.ColStart[ivar+1]; k++ {
+			icon := lp.LP.RowIdx[k]
+			Lo, Up := RowBounds(icon)
+			Act := Activity[icon] + lp.LP.ColVal[k]*Delta
+			Sum += math.Max(0.0, Lo-Act) + math.Max(0.0, Act-Up)
+		}
+		return Sum
+	}
+	for _, ivar := range Order {
+		x := Point[ivar]
+		Down := intInBounds(ivar, math.Floor(x))
+		Up := intInBounds(ivar, math.Ceil(x))
+		Value := intInBounds(ivar, math.Floor(x+0.5))
+		if Down != Up {
+			ViolDown, ViolUp := RowViolation(ivar, Down-x), RowViolation(ivar, Up-x)
+			if ViolDown < ViolUp {
+				Value = Down
+			} else if ViolUp < ViolDown {
+				Value = Up
+			}
+		}
+		if Value == x {
+			continue
+		}
+		for k := lp.LP.ColStart[ivar]; k < lp.LP.ColStart[ivar+1]; k++ {
+			Activity[lp.LP.RowIdx[k]] += lp.LP.ColVal[k] * (Value - x)
+		}
+		Point[ivar] = Value
+		NumChanged++
+	}
+	return NumChanged
+}
+
+//=======================================================================================
+// True if the integer columns have the same values at the two points
+func sameIntegers(Point1 []float64, Point2 []float64) bool {
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		if lp.LP.Cols[ivar].Type.IsInteger() && Point1[ivar] != Point2[ivar] {
+			return false
+		}
+	}
+	return true
+}
+
+//=======================================================================================
+// The integer Value moved inside the bounds of column ivar, if there is an integer inside them.
+// A semi-integer column goes to the nearer of 0 and its bounds.
+func intInBounds(ivar int, Value float64) float64 {
+	Col := &lp.LP.Cols[ivar]
+	if Col.Type.IsSemi() && Value == 0.0 {
+		return 0.0
+	}
+	if Col.Type.IsSemi() && ((Col.BndLo > 0.0 && Value < Col.BndLo) || (Col.BndUp < 0.0 && Value > Col.BndUp)) {
+		Nearest := math.Ceil(Col.BndLo - featol)
+		if Col.BndUp < 0.0 {
+			Nearest = math.Floor(Col.BndUp + featol)
+		}
+		if math.Abs(Value) <= math.Abs(Value-Nearest) {
+			return 0.0
+		}
+		return Nearest
+	}
+	if Col.BndLo > -plinfy && Value < Col.BndLo {
+		Value = math.Ceil(Col.BndLo - featol)
+	}
+	if Col.BndUp < plinfy && Value > Col.BndUp {
+		Value = math.Floor(Col.BndUp + featol)
+	}
+	return Value
+}
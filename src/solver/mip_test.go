@@ -0,0 +1,124 @@
+package solver
+
+import (
+	"lp"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+//=======================================================================================
+// Builds X, Y semi-integer in {0} + [3, 8], Z integer in [0, 5], W continuous in [0, 10] and
+// V integer in [0, 10], with the rows CAP: V <= 2.2 and SUM: X + Y + Z + W <= 100
+func buildMIPModel(t *testing.T) {
+	t.Helper()
+	lp.NewModel("MIPTEST", 1.0e10, 1.0e-6)
+	Cols := []struct {
+		Name   string
+		Type   lp.COLTYPE
+		Lo, Up float64
+	}{
+		{"X", lp.ColSI, 3.0, 8.0},
+		{"Y", lp.ColSI, 3.0, 8.0},
+		{"Z", lp.ColI, 0.0, 5.0},
+		{"W", lp.ColR, 0.0, 10.0},
+		{"V", lp.ColI, 0.0, 10.0},
+	}
+	for _, Col := range Cols {
+		lp.AddColumn(Col.Name, Col.Type, Col.Lo, Col.Up)
+	}
+	Cap, _ := lp.AddRow("CAP", lp.RowL, 2.2, 0.0)
+	lp.SetCoefficient(Cap, 4, 1.0)
+	Sum, _ := lp.AddRow("SUM", lp.RowL, 100.0, 0.0)
+	for j := 0; j < 4; j++ {
+		lp.SetCoefficient(Sum, j, 1.0)
+	}
+	if lp.EndModel() > 0 {
+		t.Fatal("EndModel failed")
+	}
+	SetTolerances(1.0e10, 1.0e-6)
+	PrintLevel = 0
+}
+
+//=======================================================================================
+func TestIntInBounds(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Type   lp.COLTYPE
+		Lo, Up float64
+		Value  float64
+		Want   float64
+	}{
+		{"integer inside", lp.ColI, 0.0, 5.0, 3.0, 3.0},
+		{"integer above", lp.ColI, 0.0, 5.0, 7.0, 5.0},
+		{"integer below", lp.ColI, 0.0, 5.0, -2.0, 0.0},
+		{"fractional bounds", lp.ColI, 0.5, 4.5, 0.0, 1.0},
+		{"fractional bounds above", lp.ColI, 0.5, 4.5, 5.0, 4.0},
+		{"semi-integer at 0", lp.ColSI, 3.0, 8.0, 0.0, 0.0},
+		{"semi-integer nearer 0", lp.ColSI, 3.0, 8.0, 1.0, 0.0},
+		{"semi-integer nearer the bound", lp.ColSI, 3.0, 8.0, 2.0, 3.0},
+		{"semi-integer above", lp.ColSI, 3.0, 8.0, 9.0, 8.0},
+		{"semi-integer halfway goes to 0", lp.ColSI, 2.0, 8.0, 1.0, 0.0},
+		{"semi-integer fractional bound", lp.ColSI, 2.5, 8.0, 2.0, 3.0},
+		{"negative semi-integer nearer 0", lp.ColSI, -8.0, -3.0, -1.0, 0.0},
+		{"negative semi-integer nearer the bound", lp.ColSI, -8.0, -3.0, -2.0, -3.0},
+		{"negative semi-integer below", lp.ColSI, -8.0, -3.0, -9.0, -8.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			lp.NewModel("INTTEST", 1.0e10, 1.0e-6)
+			x, _ := lp.AddColumn("X", tt.Type, tt.Lo, tt.Up)
+			irow, _ := lp.AddRow("R", lp.RowL, 100.0, 0.0)
+			lp.SetCoefficient(irow, x, 1.0)
+			if lp.EndModel() > 0 {
+				t.Fatal("EndModel failed")
+			}
+			SetTolerances(1.0e10, 1.0e-6)
+			if Got := intInBounds(x, tt.Value); Got != tt.Want {
+				t.Errorf("intInBounds(%v) = %v, want %v", tt.Value, Got, tt.Want)
+			}
+		})
+	}
+}
+
+//=======================================================================================
+// Each mode leaves every integer column at an integer in its domain and the continuous column
+// alone. The ordered mode rounds V down, since rounding it up would violate CAP.
+func TestRoundPoint(t *testing.T) {
+	Start := []float64{1.4, 2.6, 5.7, 0.3, 2.5} // X, Y, Z, W, V
+	tests := []struct {
+		Name        string
+		Mode        ROUNDMODE
+		Want        []float64 // nil: any integer in the domain
+		WantChanged int
+	}{
+		{"simple", RoundSimple, []float64{0.0, 3.0, 5.0, 0.3, 3.0}, 4},
+		{"ordered", RoundOrdered, []float64{0.0, 3.0, 5.0, 0.3, 2.0}, 4},
+		{"random", RoundRandom, nil, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			buildMIPModel(t)
+			Point := append([]float64(nil), Start...)
+			NumChanged := RoundPoint(Point, tt.Mode, rand.New(rand.NewSource(1)))
+			if tt.Want != nil && !reflect.DeepEqual(Point, tt.Want) {
+				t.Errorf("point %v, want %v", Point, tt.Want)
+			}
+			if NumChanged != tt.WantChanged {
+				t.Errorf("%d columns changed, want %d", NumChanged, tt.WantChanged)
+			}
+			for ivar := 0; ivar < lp.NumCols; ivar++ {
+				if !lp.LP.Cols[ivar].Type.IsInteger() {
+					if Point[ivar] != Start[ivar] {
+						t.Errorf("continuous column %s moved to %v", lp.LP.Cols[ivar].Name, Point[ivar])
+					}
+					continue
+				}
+				if Point[ivar] != math.Floor(Point[ivar]) || BoundViolation(ivar, Point[ivar]) != 0.0 {
+					t.Errorf("column %s at %v, not an integer in its domain", lp.LP.Cols[ivar].Name, Point[ivar])
+				}
+			}
+		})
+	}
+}
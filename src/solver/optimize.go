@@ -0,0 +1,121 @@
+package solver
+
+// Objective optimization after feasibility. Solve stops at the first feasible point and ignores
+// the objective row, so this mode appends an objective cut f(x) <= target as an extra L row and
+// re-runs CC, warm-started from the current best feasible point, for a sequence of targets.
+// The targets step down from the best objective by a growing step until CC fails to reach one,
+// then bisect between the best objective and the lowest failed target. CC failing at a target
+// is not a proof that the target is out of reach, so the failed targets are only a heuristic
+// bound. The objective row is minimized. A quadratic objective gives a quadratic cut, which CC
+// handles like any other quadratic row.
+
+import (
+	"fmt"
+	"lp"
+	"math"
+	"time"
+)
+
+var OptMaxSteps int = 20       // Maximum number of targets tried
+var OptRounds int = 10         // CC rounds (sample boxes) per target
+var OptTimeLimit time.Duration // Time limit on the whole optimization. 0: no limit
+var OptRelTol float64 = 1.0e-4 // Stop when the gap to the lowest failed target is below this fraction of max(1, |objective|)
+var OptFirstStep float64 = 0.1 // First step below the starting objective, as a fraction of max(1, |objective|)
+
+type OPTRESULT struct {
+	StartObj    float64   // Objective at the starting point
+	Objective   float64   // Best feasible objective reached
+	Point       []float64 // The point that reached it
+	FailedBelow float64   // Lowest target CC failed to reach (minus plinfy if none failed)
+	NumSteps    int       // Number of targets tried
+	NumImproved int       // Number of targets reached
+}
+
+//=======================================================================================
+// Minimizes the objective row starting from the feasible point Start, within OptMaxSteps
+// targets and OptTimeLimit. The CC settings are the ones Solve takes. The model is left as it
+// was: the cut row is removed before returning.
+// Status: 0(success), 1(no objective row), 2(start point not feasible), 3(cut row not added)
+func Optimize(Start []float64, AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, plinfyIn float64, featolIn float64) (Result OPTRESULT, Status int) {
+
+	plinfy, featol = plinfyIn, featolIn
+
+	ObjRow := lp.LP.ObjRow
+	if ObjRow < 0 {
+		fmt.Println("Error: the model has no objective row to optimize.")
+		return Result, 1
+	}
+	if _, NINF, _, _, _, _, _ := TestPoint(Start); NINF > 0 {
+		fmt.Println("Error: the optimization must start from a feasible point.")
+		return Result, 2
+	}
+	Result.StartObj, _ = lp.ConBodyValue(ObjRow, Start)
+	Result.Objective = Result.StartObj
+	Result.Point = append([]float64(nil), Start...)
+	Result.FailedBelow = -plinfy
+
+	// The objective cut, with the objective row's coefficients
+	var Cols []int
+	var Vals []float64
+	for k := lp.LP.RowStart[ObjRow]; k < lp.LP.RowStart[ObjRow+1]; k++ {
+		Cols = append(Cols, lp.LP.ColIdx[k])
+		Vals = append(Vals, lp.LP.RowVal[k])
+	}
+	CutRow, CutStatus := lp.AppendRow("OBJCUT", lp.RowL, -plinfy, Result.Objective, Cols, Vals)
+	if CutStatus > 0 {
+		return Result, 3
+	}
+	defer lp.DeleteLastRow()
+	if len(lp.LP.Rows[ObjRow].QTerms) > 0 {
+		// A quadratic objective makes a quadratic cut
+		if lp.SetQuadTerms(CutRow, lp.LP.Rows[ObjRow].QTerms) > 0 {
+			return Result, 3
+		}
+	}
+
+	// Inner solves run quietly with a small number of rounds
+	SavePrintLevel, SaveMaxRounds := PrintLevel, MaxRounds
+	PrintLevel, MaxRounds = 0, OptRounds
+	defer func() { PrintLevel, MaxRounds = SavePrintLevel, SaveMaxRounds }()
+
+	StartTime := time.Now()
+	Step := OptFirstStep * math.Max(1.0, math.Abs(Result.Objective))
+	for Result.NumSteps < OptMaxSteps {
+		if OptTimeLimit > 0 && time.Since(StartTime) > OptTimeLimit {
+			break
+		}
+		Scale := math.Max(1.0, math.Abs(Result.Objective))
+		if Result.Objective-Result.FailedBelow <= OptRelTol*Scale {
+			break
+		}
+		Target := Result.Objective - Step
+		if Result.FailedBelow > -plinfy {
+			Target = 0.5 * (Result.Objective + Result.FailedBelow)
+		}
+		lp.SetRowBounds(CutRow, -plinfy, Target)
+		Result.NumSteps++
+
+		Point, SolveStatus := solveFrom(WARMSTART{Points: [][]float64{Result.Point}}, AlphaIn, BetaIn, MaxItnsIn, MaxSwarmPtsIn, plinfy, featol)
+		Obj, _ := lp.ConBodyValue(ObjRow, Point)
+		if SolveStatus == 0 && Obj < Result.Objective {
+			Result.Objective = Obj
+			copy(Result.Point, Point)
+			Result.NumImproved++
+			Step = 2.0 * Step
+			if Obj <= Result.FailedBelow {
+				// CC has now gone below a target it failed to reach before: step down again
+				Result.FailedBelow = -plinfy
+			}
+		} else {
+			Result.FailedBelow = Target
+		}
+		if SavePrintLevel > 0 {
+			Outcome := "not reached"
+			if SolveStatus == 0 {
+				Outcome = fmt.Sprint("reached: objective ", Obj)
+			}
+			fmt.Println("  Objective target", Target, Outcome, "  best", Result.Objective)
+		}
+	}
+	return Result, 0
+}
@@ -0,0 +1,228 @@
+package solver
+
+// The feasibility oracle: the single place that decides how far a row, a bound or an integer
+// column may be off before it counts as violated. GetViolation, TestPoint, GetSFD, GetCV,
+// CCSimple, the projections and the sampling routines all go through it, so they agree on
+// what "feasible" means. The legacy routines (CCOriginal*, CCImpact, GetCV1) keep their own
+// Alpha tests.
+//
+// Rows: featol is interpreted according to TolMode.
+//   absolute: |LHS - RHS| <= featol
+//   relative: |LHS - RHS| <= featol * max(1, |RHS|)
+//   rownorm:  |LHS - RHS| <= featol * sqrt(GradVecLenSq), i.e. feasibility distance <= featol
+// Quadratic rows use the absolute tolerance in the row-norm mode, since their gradient varies
+// with the point.
+//
+// Columns: a bound's gradient is a unit vector, so the row-norm mode treats bounds like the
+// absolute one. A semi-continuous or semi-integer column may also be 0, and its violation is
+// measured to the nearer of 0 and its bounds. In the MIP mode an integer column more than
+// featol from the nearest integer is violated too, whatever the tolerance mode (IntViolation).
+
+import (
+	"fmt"
+	"lp"
+	"math"
+)
+
+type TOLMODE int
+
+const (
+	TolAbsolute TOLMODE = iota
+	TolRelative
+	TolRowNorm
+)
+
+var TolModes = []TOLMODE{TolAbsolute, TolRelative, TolRowNorm}
+
+var TolMode TOLMODE // Tolerance mode used by the solver. The zero value is the absolute mode.
+
+//=======================================================================================
+func (m TOLMODE) String() string {
+	switch m {
+	case TolRelative:
+		return "relative"
+	case TolRowNorm:
+		return "rownorm"
+	}
+	return "absolute"
+}
+
+//=======================================================================================
+// Found is false if Name is not a tolerance mode
+func ParseTolMode(Name string) (Mode TOLMODE, Found bool) {
+	for _, Mode = range TolModes {
+		if Mode.String() == Name {
+			return Mode, true
+		}
+	}
+	return TolAbsolute, false
+}
+
+//=======================================================================================
+// Makes *TOLMODE a flag.Value, so a tolerance mode can be set from the command line
+func (m *TOLMODE) Set(Name string) error {
+	Mode, Found := ParseTolMode(Name)
+	if !Found {
+		return fmt.Errorf("unknown tolerance mode %q: use absolute, relative or rownorm", Name)
+	}
+	*m = Mode
+	return nil
+}
+
+//=======================================================================================
+// The largest |LHS - RHS| allowed on the side of row icon whose right hand side is RHS
+func RowTolerance(icon int, RHS float64, Mode TOLMODE) float64 {
+	switch Mode {
+	case TolRelative:
+		return featol * math.Max(1.0, math.Abs(RHS))
+	case TolRowNorm:
+		if len(lp.LP.Rows[icon].QTerms) > 0 {
+			return featol // the gradient of a quadratic row depends on the point
+		}
+		return featol * math.Sqrt(lp.LP.Rows[icon].GradVecLenSq)
+	}
+	return featol
+}
+
+//=======================================================================================
+// The largest violation allowed for a bound with the given value
+func BoundTolerance(Bound float64, Mode TOLMODE) float64 {
+	if Mode == TolRelative {
+		return featol * math.Max(1.0, math.Abs(Bound))
+	}
+	return featol
+}
+
+//=======================================================================================
+// Violation of the bounds on column ivar at value x, signed like GetViolation (the bound minus
+// x, so positive means x must increase), or 0 if the bounds are satisfied within tolerance.
+func BoundViolation(ivar int, x float64) float64 {
+	return BoundViolationMode(ivar, x, TolMode)
+}
+
+//=======================================================================================
+func BoundViolationMode(ivar int, x float64, Mode TOLMODE) (Violation float64) {
+	Col := &lp.LP.Cols[ivar]
+	if Col.BndLo > -plinfy && x < Col.BndLo-BoundTolerance(Col.BndLo, Mode) {
+		Violation = Col.BndLo - x
+	} else if Col.BndUp < plinfy && x > Col.BndUp+BoundTolerance(Col.BndUp, Mode) {
+		Violation = Col.BndUp - x
+	}
+	if Violation != 0.0 && Col.Type.IsSemi() {
+		// The other part of the domain: 0
+		if math.Abs(x) <= BoundTolerance(0.0, Mode) {
+			return 0.0
+		}
+		if math.Abs(x) < math.Abs(Violation) {
+			return -x
+		}
+	}
+	return Violation
+}
+
+//=======================================================================================
+// The bounds of the convex hull of the domain of column ivar, used by the LP relaxations:
+// the column bounds, widened to take in 0 for a semi-continuous or semi-integer column
+func HullBounds(ivar int) (Lo float64, Up float64) {
+	Col := &lp.LP.Cols[ivar]
+	Lo, Up = Col.BndLo, Col.BndUp
+	if Col.Type.IsSemi() {
+		Lo, Up = math.Min(Lo, 0.0), math.Max(Up, 0.0)
+	}
+	return Lo, Up
+}
+
+//=======================================================================================
+// For a semi-continuous or semi-integer column, moves a value in the gap between 0 and the
+// bounds to the nearer of the two. Other values and columns are left alone.
+func SnapSemi(ivar int, x float64) float64 {
+	Col := &lp.LP.Cols[ivar]
+	if !Col.Type.IsSemi() {
+		return x
+	}
+	if Col.BndLo > 0.0 && x > 0.0 && x < Col.BndLo {
+		if x < 0.5*Col.BndLo {
+			return 0.0
+		}
+		return Col.BndLo
+	}
+	if Col.BndUp < 0.0 && x < 0.0 && x > Col.BndUp {
+		if x > 0.5*Col.BndUp {
+			return 0.0
+		}
+		return Col.BndUp
+	}
+	return x
+}
+
+//=======================================================================================
+// Integrality violation of column ivar at value x in the MIP mode: the nearest integer minus x,
+// signed like BoundViolation, or 0 if x is within featol of an integer, the column is
+// continuous or the MIP mode is off.
+func IntViolation(ivar int, x float64) float64 {
+	if !mipActive() || !lp.LP.Cols[ivar].Type.IsInteger() {
+		return 0.0
+	}
+	if Violation := math.Floor(x+0.5) - x; math.Abs(Violation) > featol {
+		return Violation
+	}
+	return 0.0
+}
+
+//=======================================================================================
+// Counts the rows, custom constraints and bounds violated at a point under the given tolerance
+// mode, the violated special ordered sets, and in the MIP mode the fractional integer columns.
+// Status: 0(success), 1(trouble evaluating one or more rows or constraints)
+func CountViolations(Point []float64, Mode TOLMODE) (Status int, NINF int, MaxViol float64) {
+
+	for icon := 0; icon < lp.NumRows; icon++ {
+		FVStatus, ViolStatus, Violation := GetViolationMode(icon, Point, Mode)
+		if FVStatus > 0 {
+			Status = 1
+			continue
+		}
+		if ViolStatus == 0 {
+			NINF++
+			MaxViol = math.Max(MaxViol, math.Abs(Violation))
+		}
+	}
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		if Violation := BoundViolationMode(ivar, Point[ivar], Mode); Violation != 0.0 {
+			NINF++
+			MaxViol = math.Max(MaxViol, math.Abs(Violation))
+		}
+		if Violation := IntViolation(ivar, Point[ivar]); Violation != 0.0 {
+			NINF++
+			MaxViol = math.Max(MaxViol, math.Abs(Violation))
+		}
+	}
+	for ic := range Constraints {
+		CStatus, Violation, _ := ConstraintViolation(ic, Point, Mode)
+		if CStatus > 0 {
+			Status = 1
+			continue
+		}
+		if Violation != 0.0 {
+			NINF++
+			MaxViol = math.Max(MaxViol, math.Abs(Violation))
+		}
+	}
+	for iset := range lp.LP.SOS {
+		if Violation, _, _ := SOSViolation(iset, Point); Violation != 0.0 {
+			NINF++
+			MaxViol = math.Max(MaxViol, Violation)
+		}
+	}
+	return Status, NINF, MaxViol
+}
+
+//=======================================================================================
+// The tolerance modes under which the point is feasible, e.g. [relative rownorm]
+func SatisfiedTolModes(Point []float64) (Modes []string) {
+	for _, Mode := range TolModes {
+		if Status, NINF, _ := CountViolations(Point, Mode); Status == 0 && NINF == 0 {
+			Modes = append(Modes, Mode.String())
+		}
+	}
+	return Modes
+}
@@ -0,0 +1,104 @@
+package solver
+
+import (
+	"lp"
+	"testing"
+)
+
+//=======================================================================================
+// Builds the single row Coefs[0]*x + Coefs[1]*y <= RHS over free x and y, plus y*y if
+// Quadratic, and returns a point whose body is RHS + Excess
+func buildOracleModel(t *testing.T, Coefs []float64, RHS float64, Quadratic bool, Excess float64) []float64 {
+	t.Helper()
+	lp.NewModel("ORACLETEST", 1.0e10, 1.0e-6)
+	x, _ := lp.AddColumn("X", lp.ColR, -1.0e10, 1.0e10)
+	y, _ := lp.AddColumn("Y", lp.ColR, -1.0e10, 1.0e10)
+	irow, _ := lp.AddRow("R", lp.RowL, RHS, 0.0)
+	lp.SetCoefficient(irow, x, Coefs[0])
+	lp.SetCoefficient(irow, y, Coefs[1])
+	if lp.EndModel() > 0 {
+		t.Fatal("EndModel failed")
+	}
+	if Quadratic {
+		// y*y, which is 0 at the point
+		lp.SetQuadTerms(irow, []lp.QTERM{{Col1: y, Col2: y, Value: 1.0}})
+	}
+	SetTolerances(1.0e10, 1.0e-6)
+	PrintLevel = 0
+	return []float64{(RHS + Excess) / Coefs[0], 0.0}
+}
+
+//=======================================================================================
+// featol is 1e-6: absolute allows 1e-6, relative 1e-6*max(1, |RHS|), row-norm 1e-6 times the
+// norm of the row's gradient, or 1e-6 on a quadratic row
+func TestRowToleranceModes(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Coefs     []float64
+		RHS       float64
+		Quadratic bool
+		Excess    float64
+		Violated  [3]bool // absolute, relative, rownorm
+	}{
+		{"within every tolerance", []float64{3.0, 4.0}, 1000.0, false, 0.5e-6, [3]bool{false, false, false}},
+		{"large RHS: only absolute", []float64{3.0, 4.0}, 1000.0, false, 2.0e-6, [3]bool{true, false, false}},
+		{"large RHS: absolute and rownorm", []float64{3.0, 4.0}, 1000.0, false, 1.0e-4, [3]bool{true, false, true}},
+		{"beyond every tolerance", []float64{3.0, 4.0}, 1000.0, false, 2.0e-3, [3]bool{true, true, true}},
+		{"short gradient: only rownorm", []float64{0.3, 0.4}, 0.1, false, 0.7e-6, [3]bool{false, false, true}},
+		{"quadratic row: rownorm is absolute", []float64{3.0, 4.0}, 1000.0, true, 2.0e-6, [3]bool{true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			Point := buildOracleModel(t, tt.Coefs, tt.RHS, tt.Quadratic, tt.Excess)
+			for k, Mode := range TolModes {
+				FVStatus, ViolStatus, _ := GetViolationMode(0, Point, Mode)
+				if FVStatus > 0 {
+					t.Fatalf("%v: numerical problem", Mode)
+				}
+				if Violated := ViolStatus == 0; Violated != tt.Violated[k] {
+					t.Errorf("%v: violated %v, want %v", Mode, Violated, tt.Violated[k])
+				}
+				if _, NINF, _ := CountViolations(Point, Mode); (NINF > 0) != tt.Violated[k] {
+					t.Errorf("%v: CountViolations gives NINF %d", Mode, NINF)
+				}
+			}
+		})
+	}
+}
+
+//=======================================================================================
+// Bounds: the row-norm mode treats them like the absolute one, and a semi-continuous column
+// may also be 0
+func TestBoundToleranceModes(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Type     lp.COLTYPE
+		Lo, Up   float64
+		x        float64
+		Violated [3]bool // absolute, relative, rownorm
+	}{
+		{"within every tolerance", lp.ColR, 0.0, 1000.0, 1000.0 + 0.5e-6, [3]bool{false, false, false}},
+		{"large bound: absolute and rownorm", lp.ColR, 0.0, 1000.0, 1000.0 + 2.0e-6, [3]bool{true, false, true}},
+		{"beyond every tolerance", lp.ColR, 0.0, 1000.0, 1000.0 + 2.0e-3, [3]bool{true, true, true}},
+		{"lower bound", lp.ColR, -1000.0, 0.0, -1000.0 - 2.0e-6, [3]bool{true, false, true}},
+		{"semi-continuous at 0", lp.ColSC, 5.0, 10.0, 0.5e-6, [3]bool{false, false, false}},
+		{"semi-continuous in the gap", lp.ColSC, 5.0, 10.0, 2.0, [3]bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			lp.NewModel("BOUNDTEST", 1.0e10, 1.0e-6)
+			x, _ := lp.AddColumn("X", tt.Type, tt.Lo, tt.Up)
+			irow, _ := lp.AddRow("R", lp.RowL, 1.0e6, 0.0)
+			lp.SetCoefficient(irow, x, 1.0)
+			if lp.EndModel() > 0 {
+				t.Fatal("EndModel failed")
+			}
+			SetTolerances(1.0e10, 1.0e-6)
+			for k, Mode := range TolModes {
+				if Violated := BoundViolationMode(x, tt.x, Mode) != 0.0; Violated != tt.Violated[k] {
+					t.Errorf("%v: violated %v, want %v", Mode, Violated, tt.Violated[k])
+				}
+			}
+		})
+	}
+}
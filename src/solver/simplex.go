@@ -0,0 +1,620 @@
+package solver
+
+// Sparse bounded primal simplex over the lp model, used to confirm infeasibility, to finish off
+// a nearly feasible CC incumbent and (in phase 2) to optimize the objective row.
+//
+// Each included row i gets a logical s_i = a_i.x carrying the row's bounds, so the constraints
+// are A x - s = 0 with bounds on every variable. The basis inverse is kept in product form: an
+// eta file on top of the all-logical basis -I, rebuilt once the pivots since the last rebuild
+// outnumber both SimplexRefactor and the eta vectors the rebuild produced. Phase 1
+// minimizes the sum of the bound violations of the basic variables (the composite method), so
+// it can start from any basis. Nonbasic variables may sit anywhere within their bounds, which
+// lets a warm start keep the CC point's values.
+//
+// Warm start: the rows that GetViolation reports tight at the start point (ViolStatus == 2)
+// each get a structural column pivoted into the basis in place of their logical, and the
+// logical is held at the bound it is tight to. This crash basis makes CC act as the crash
+// procedure for the LP solve.
+
+import (
+	"fmt"
+	"lp"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+type SIMPLEXOPTS struct {
+	Rows     []bool    // Rows to include, by model row number. nil: every row except nonbinding ones
+	UseLo    []bool    // Column lower bounds to include. nil: all. Left out bounds are minus infinity
+	UseUp    []bool    // Column upper bounds to include. nil: all. Left out bounds are plus infinity
+	Start    []float64 // Warm start point: crash basis from the rows tight there. nil: cold start at the bounds
+	Optimize bool      // Phase 2: minimize the objective row once feasible
+	MaxIts   int       // Iteration limit. 0: a default based on the model size
+}
+
+type SIMPLEXRESULT struct {
+	Point      []float64 // Final values of the columns
+	Objective  float64   // Objective row activity at Point (0 if there is no objective row)
+	SumInf     float64   // Sum of the bound violations of the basic variables at the end
+	Iterations int       // Total simplex iterations
+	Phase1Its  int       // Iterations spent in phase 1
+	NumCrash   int       // Structurals put in the basis by the crash
+	NumRefacts int       // Number of basis refactorizations
+	RowList    []int     // Model row of each simplex row
+	Basis      []int     // Final basis: in each position a column j, or NumCols+k for the logical of simplex row k
+	Phase1Cost []float64 // On an infeasible end, the phase 1 cost of each basis position: -1 below its bounds, 1 above, else 0
+}
+
+var SimplexRefactor int = 100       // Least number of pivots between refactorizations of the basis
+var SimplexPerturb float64 = 1.0e-7 // Relative size of the bound perturbation against degeneracy
+
+// One eta vector of the product form: the FTRAN'd entering column, pivoting on Row
+type etaVec struct {
+	Row int
+	Piv float64   // Entry in the pivot row
+	Idx []int     // Rows of the other nonzeros
+	Val []float64 // Their values
+}
+
+// Working state of one simplex solve
+type simplexLP struct {
+	m, n    int
+	RowList []int     // Model row of each simplex row
+	RowMap  []int     // Simplex row of each model row, or -1
+	Lo, Up  []float64 // Bounds of the n structurals followed by the m logicals
+	X       []float64 // Values of all variables
+	Cost    []float64 // Phase 2 costs
+	Basis   []int     // Variable in each basis position
+	Pos     []int     // Basis position of each variable, or -1 if nonbasic
+	Etas    []etaVec
+	NumBase int // Length of the eta file after the last refactorization
+}
+
+//=======================================================================================
+// Solves the LP given by the options over the current model.
+// Status: 0(feasible; optimal too if Opts.Optimize), 1(infeasible), 2(unbounded objective),
+// 3(iteration limit), 4(numerical trouble), 5(a row it would use is quadratic)
+func Simplex(Opts SIMPLEXOPTS) (Result SIMPLEXRESULT, Status int) {
+
+	const DualTol = 1.0e-9
+	const PivTol = 1.0e-9
+
+	for i := 0; i < lp.NumRows; i++ {
+		Row := &lp.LP.Rows[i]
+		Used := Row.Type != lp.RowN && (Opts.Rows == nil || Opts.Rows[i])
+		if len(Row.QTerms) > 0 && (Used || (Opts.Optimize && i == lp.LP.ObjRow)) {
+			fmt.Println("Error: row", Row.Name, "is quadratic. The simplex handles linear rows only.")
+			return Result, 5
+		}
+	}
+
+	S := newSimplexLP(Opts)
+	m, n := S.m, S.n
+	Result.NumCrash = S.crash(Opts.Start)
+	S.refactor()
+	Result.NumRefacts = 1
+
+	MaxIts := Opts.MaxIts
+	if MaxIts <= 0 {
+		MaxIts = 20*(m+n) + 1000
+	}
+	CB := make([]float64, m)    // Costs of the basic variables, then the duals
+	Pivot := make([]float64, m) // The entering column, FTRAN'd
+	Fresh := true               // true: the basic values were just recomputed
+	NumDegenerate := 0
+	var SavedLo, SavedUp []float64 // The bounds before perturbation, while they are perturbed
+	Perturbed := false             // true once the bounds have been perturbed (it is done only once)
+	Phase1 := true
+
+	for {
+		// Which phase: the cost of each basic variable
+		Result.SumInf = 0.0
+		for k := 0; k < m; k++ {
+			b := S.Basis[k]
+			switch {
+			case S.X[b] < S.Lo[b]-featol:
+				CB[k] = -1.0
+				Result.SumInf += S.Lo[b] - S.X[b]
+			case S.X[b] > S.Up[b]+featol:
+				CB[k] = 1.0
+				Result.SumInf += S.X[b] - S.Up[b]
+			default:
+				CB[k] = 0.0
+			}
+		}
+		Phase1 = Result.SumInf > 0.0
+		if !Phase1 {
+			if !Opts.Optimize {
+				break
+			}
+			for k := 0; k < m; k++ {
+				CB[k] = S.Cost[S.Basis[k]]
+			}
+		}
+		if Result.Iterations >= MaxIts {
+			if PrintLevel > 0 {
+				fmt.Println("Simplex: iteration limit", MaxIts, "reached.")
+			}
+			Status = 3
+			break
+		}
+		S.btran(CB)
+
+		// Pricing: Dantzig's rule, or Bland's rule (with its ratio test below) after a run of
+		// degenerate steps, to break cycles
+		Enter, Dir, Best := -1, 0.0, 0.0
+		for v := 0; v < n+m; v++ {
+			if S.Pos[v] >= 0 || S.Lo[v] == S.Up[v] {
+				continue
+			}
+			d := 0.0
+			if v < n {
+				if !Phase1 {
+					d = S.Cost[v]
+				}
+				for p := lp.LP.ColStart[v]; p < lp.LP.ColStart[v+1]; p++ {
+					if k := S.RowMap[lp.LP.RowIdx[p]]; k >= 0 {
+						d -= CB[k] * lp.LP.ColVal[p]
+					}
+				}
+			} else {
+				d = CB[v-n] // the logical's column is -e
+			}
+			var Score, Sign float64
+			if d < -DualTol && S.X[v] < S.Up[v] {
+				Score, Sign = -d, 1.0
+			} else if d > DualTol && S.X[v] > S.Lo[v] {
+				Score, Sign = d, -1.0
+			} else {
+				continue
+			}
+			if NumDegenerate > 50 && Perturbed {
+				// Bland's rule: the lowest eligible index
+				Enter, Dir = v, Sign
+				break
+			}
+			if Score > Best {
+				Best, Enter, Dir = Score, v, Sign
+			}
+		}
+		if Enter < 0 {
+			if !Fresh || SavedLo != nil {
+				// Confirm on freshly computed values, with the bounds as given, before concluding
+				if SavedLo != nil {
+					S.unperturb(SavedLo, SavedUp)
+					SavedLo, SavedUp = nil, nil
+				}
+				S.refactor()
+				Result.NumRefacts++
+				Fresh = true
+				continue
+			}
+			if Phase1 {
+				Status = 1
+			}
+			break
+		}
+
+		// Ratio test (two passes, Harris style). Basic variable k moves at Rate = -Dir*Pivot[k].
+		S.column(Enter, Pivot)
+		S.ftran(Pivot)
+		MaxPivot := 0.0
+		for k := 0; k < m; k++ {
+			MaxPivot = math.Max(MaxPivot, math.Abs(Pivot[k]))
+		}
+		Tol := PivTol * math.Max(1.0, MaxPivot)
+		Limit := func(k int, Slack float64) (Theta float64, Target float64) {
+			b := S.Basis[k]
+			Rate := -Dir * Pivot[k]
+			Theta = plinfy
+			switch {
+			case math.Abs(Pivot[k]) < Tol:
+			case Phase1 && S.X[b] < S.Lo[b]-featol:
+				// Infeasible below: blocks where it becomes feasible
+				if Rate > 0.0 {
+					Theta, Target = (S.Lo[b]-S.X[b])/Rate, S.Lo[b]
+				}
+			case Phase1 && S.X[b] > S.Up[b]+featol:
+				if Rate < 0.0 {
+					Theta, Target = (S.Up[b]-S.X[b])/Rate, S.Up[b]
+				}
+			case Rate < 0.0 && S.Lo[b] > -plinfy:
+				Theta, Target = math.Max(0.0, (S.X[b]-S.Lo[b]+Slack)/(-Rate)), S.Lo[b]
+			case Rate > 0.0 && S.Up[b] < plinfy:
+				Theta, Target = math.Max(0.0, (S.Up[b]-S.X[b]+Slack)/Rate), S.Up[b]
+			}
+			return Theta, Target
+		}
+		ThetaMax := plinfy
+		for k := 0; k < m; k++ {
+			if Theta, _ := Limit(k, featol); Theta < ThetaMax {
+				ThetaMax = Theta
+			}
+		}
+		Bland := NumDegenerate > 50 && Perturbed
+		Leave, Theta, Target := -1, plinfy, 0.0
+		for k := 0; k < m; k++ {
+			t, Tgt := Limit(k, 0.0)
+			switch {
+			case t >= plinfy:
+			case Bland:
+				// Bland's rule: the smallest ratio, ties to the lowest variable index
+				if t < Theta || (t == Theta && S.Basis[k] < S.Basis[Leave]) {
+					Leave, Theta, Target = k, t, Tgt
+				}
+			case t <= ThetaMax && (Leave < 0 || math.Abs(Pivot[k]) > math.Abs(Pivot[Leave])):
+				Leave, Theta, Target = k, t, Tgt
+			}
+		}
+		Flip := plinfy // the entering variable reaching its own bound
+		if Dir > 0.0 && S.Up[Enter] < plinfy {
+			Flip = S.Up[Enter] - S.X[Enter]
+		} else if Dir < 0.0 && S.Lo[Enter] > -plinfy {
+			Flip = S.X[Enter] - S.Lo[Enter]
+		}
+		if Flip <= Theta {
+			Leave, Theta = -1, Flip
+		}
+		if Theta >= plinfy {
+			if Phase1 {
+				Status = 4 // the phase 1 objective is bounded below
+			} else {
+				Status = 2
+			}
+			break
+		}
+
+		// Move
+		Result.Iterations++
+		if Phase1 {
+			Result.Phase1Its++
+		}
+		if Theta <= 1.0e-12 {
+			NumDegenerate++
+		} else {
+			NumDegenerate = 0
+		}
+		if NumDegenerate > 50 && !Perturbed {
+			SavedLo, SavedUp = S.perturb()
+			Perturbed = true
+			NumDegenerate = 0
+		}
+		S.X[Enter] += Dir * Theta
+		for k := 0; k < m; k++ {
+			S.X[S.Basis[k]] -= Dir * Theta * Pivot[k]
+		}
+		Fresh = false
+		if Leave < 0 {
+			if Dir > 0.0 {
+				S.X[Enter] = S.Up[Enter]
+			} else {
+				S.X[Enter] = S.Lo[Enter]
+			}
+			continue
+		}
+		b := S.Basis[Leave]
+		S.X[b] = Target
+		S.addEta(Leave, Pivot)
+		S.Basis[Leave] = Enter
+		S.Pos[Enter] = Leave
+		S.Pos[b] = -1
+		if len(S.Etas) >= S.NumBase+SimplexRefactor && len(S.Etas) >= 2*S.NumBase {
+			S.refactor()
+			Result.NumRefacts++
+			Fresh = true
+		}
+	}
+
+	Result.Point = make([]float64, n)
+	copy(Result.Point, S.X[:n])
+	for j := 0; j < n; j++ {
+		Result.Objective += S.Cost[j] * S.X[j]
+	}
+	Result.RowList = S.RowList
+	Result.Basis = append([]int(nil), S.Basis...)
+	if Status == 1 {
+		// The costs that, with the final basis, give the phase 1 duals: a Farkas certificate
+		Result.Phase1Cost = make([]float64, m)
+		for k, b := range S.Basis {
+			if S.X[b] < S.Lo[b]-featol {
+				Result.Phase1Cost[k] = -1.0
+			} else if S.X[b] > S.Up[b]+featol {
+				Result.Phase1Cost[k] = 1.0
+			}
+		}
+	}
+	if PrintLevel > 1 {
+		fmt.Println("Simplex: status", Status, "after", Result.Iterations, "iterations (", Result.Phase1Its, "in phase 1 ),",
+			Result.NumCrash, "crash columns, sum of infeasibilities", Result.SumInf)
+	}
+	return Result, Status
+}
+
+//=======================================================================================
+// Widens every finite bound by a small random amount, so that basic variables sitting exactly
+// on their bounds (common on models with zero right hand sides) no longer block every step.
+// Returns the original bounds for unperturb.
+func (S *simplexLP) perturb() (SavedLo []float64, SavedUp []float64) {
+
+	SavedLo = append([]float64(nil), S.Lo...)
+	SavedUp = append([]float64(nil), S.Up...)
+	RandNum := rand.New(rand.NewSource(1))
+	for v := range S.Lo {
+		if S.Lo[v] > -plinfy {
+			S.Lo[v] -= SimplexPerturb * (1.0 + math.Abs(S.Lo[v])) * (0.5 + RandNum.Float64())
+		}
+		if S.Up[v] < plinfy {
+			S.Up[v] += SimplexPerturb * (1.0 + math.Abs(S.Up[v])) * (0.5 + RandNum.Float64())
+		}
+	}
+	return SavedLo, SavedUp
+}
+
+//=======================================================================================
+// Restores the bounds saved by perturb and moves the nonbasic variables back inside them.
+// The caller recomputes the basic values.
+func (S *simplexLP) unperturb(SavedLo []float64, SavedUp []float64) {
+	copy(S.Lo, SavedLo)
+	copy(S.Up, SavedUp)
+	for v := range S.X {
+		if S.Pos[v] < 0 {
+			S.X[v] = math.Max(S.Lo[v], math.Min(S.Up[v], S.X[v]))
+		}
+	}
+}
+
+//=======================================================================================
+// Sets up the rows, bounds and costs for a solve
+func newSimplexLP(Opts SIMPLEXOPTS) *simplexLP {
+
+	S := new(simplexLP)
+	S.n = lp.NumCols
+	S.RowMap = make([]int, lp.NumRows)
+	for i := 0; i < lp.NumRows; i++ {
+		S.RowMap[i] = -1
+		if lp.LP.Rows[i].Type == lp.RowN || (Opts.Rows != nil && !Opts.Rows[i]) {
+			continue
+		}
+		S.RowMap[i] = len(S.RowList)
+		S.RowList = append(S.RowList, i)
+	}
+	S.m = len(S.RowList)
+	N := S.n + S.m
+	S.Lo = make([]float64, N)
+	S.Up = make([]float64, N)
+	S.X = make([]float64, N)
+	S.Cost = make([]float64, N)
+	S.Pos = make([]int, N)
+	S.Basis = make([]int, S.m)
+	for j := 0; j < S.n; j++ {
+		S.Lo[j], S.Up[j] = -plinfy, plinfy
+		Lo, Up := HullBounds(j)
+		if Opts.UseLo == nil || Opts.UseLo[j] {
+			S.Lo[j] = Lo
+		}
+		if Opts.UseUp == nil || Opts.UseUp[j] {
+			S.Up[j] = Up
+		}
+	}
+	for k, i := range S.RowList {
+		S.Lo[S.n+k], S.Up[S.n+k] = RowBounds(i)
+	}
+	if Opts.Optimize && lp.LP.ObjRow >= 0 {
+		for p := lp.LP.RowStart[lp.LP.ObjRow]; p < lp.LP.RowStart[lp.LP.ObjRow+1]; p++ {
+			S.Cost[lp.LP.ColIdx[p]] = lp.LP.RowVal[p]
+		}
+	}
+	return S
+}
+
+//=======================================================================================
+// Starting values and basis. Cold: structurals at a finite bound (0 if free) and every logical
+// basic. Warm: structurals at the start point (moved into their bounds), and a structural
+// basic in each row tight there. Returns the number of structurals put in the basis.
+func (S *simplexLP) crash(Start []float64) (NumCrash int) {
+
+	n := S.n
+	for v := range S.Pos {
+		S.Pos[v] = -1
+	}
+	for k := 0; k < S.m; k++ {
+		S.Basis[k] = n + k
+		S.Pos[n+k] = k
+	}
+	for j := 0; j < n; j++ {
+		switch {
+		case Start != nil:
+			S.X[j] = math.Max(S.Lo[j], math.Min(S.Up[j], Start[j]))
+		case S.Lo[j] > -plinfy:
+			S.X[j] = S.Lo[j]
+		case S.Up[j] < plinfy:
+			S.X[j] = S.Up[j]
+		}
+	}
+	if Start == nil {
+		return 0
+	}
+
+	Pivot := make([]float64, S.m)
+	for k, i := range S.RowList {
+		FVStatus, ViolStatus, _ := GetViolation(i, S.X[:n])
+		if FVStatus > 0 || ViolStatus != 2 {
+			continue
+		}
+		// The largest element of the row whose column can move and is not basic yet
+		Best, BestAbs := -1, 0.0
+		for p := lp.LP.RowStart[i]; p < lp.LP.RowStart[i+1]; p++ {
+			j := lp.LP.ColIdx[p]
+			if S.Pos[j] >= 0 || S.Lo[j] == S.Up[j] {
+				continue
+			}
+			if a := math.Abs(lp.LP.RowVal[p]); a > BestAbs {
+				Best, BestAbs = j, a
+			}
+		}
+		if Best < 0 {
+			continue
+		}
+		S.column(Best, Pivot)
+		S.ftran(Pivot)
+		MaxPivot := 0.0
+		for _, a := range Pivot {
+			MaxPivot = math.Max(MaxPivot, math.Abs(a))
+		}
+		if math.Abs(Pivot[k]) < 1.0e-7*MaxPivot {
+			continue
+		}
+		// The logical leaves at the bound the row is tight to
+		Act, _ := lp.ConBodyValue(i, S.X[:n])
+		S.X[n+k] = math.Max(S.Lo[n+k], math.Min(S.Up[n+k], Act))
+		S.addEta(k, Pivot)
+		S.Basis[k] = Best
+		S.Pos[Best] = k
+		S.Pos[n+k] = -1
+		NumCrash++
+	}
+	return NumCrash
+}
+
+//=======================================================================================
+// Rebuilds the eta file for the current basic variables and recomputes their values. A basic
+// logical keeps the position of its row; the basic structurals take the other positions with
+// partial pivoting. A structural that would make the basis singular becomes nonbasic, and the
+// logical of the row left over takes its place.
+func (S *simplexLP) refactor() {
+
+	n, m := S.n, S.m
+	var Structurals []int
+	for k := 0; k < m; k++ {
+		if b := S.Basis[k]; b < n {
+			Structurals = append(Structurals, b)
+			S.Pos[b] = -1
+		}
+	}
+	S.Etas = S.Etas[:0]
+	Taken := make([]bool, m)
+	for k := 0; k < m; k++ {
+		S.Basis[k] = -1
+		if S.Pos[n+k] >= 0 {
+			S.Basis[k] = n + k
+			S.Pos[n+k] = k
+			Taken[k] = true
+		}
+	}
+	// Short columns first keeps the fill-in of the eta file down
+	sort.Slice(Structurals, func(a, b int) bool {
+		return lp.LP.Cols[Structurals[a]].NumEl < lp.LP.Cols[Structurals[b]].NumEl
+	})
+	Pivot := make([]float64, m)
+	for _, q := range Structurals {
+		S.column(q, Pivot)
+		S.ftran(Pivot)
+		r, Best := -1, 0.0
+		for k := 0; k < m; k++ {
+			if !Taken[k] && math.Abs(Pivot[k]) > Best {
+				r, Best = k, math.Abs(Pivot[k])
+			}
+		}
+		if Best < 1.0e-9 {
+			continue // q stays nonbasic at its current value
+		}
+		S.addEta(r, Pivot)
+		S.Basis[r] = q
+		S.Pos[q] = r
+		Taken[r] = true
+	}
+	for k := 0; k < m; k++ {
+		if S.Basis[k] < 0 {
+			S.Basis[k] = n + k
+			S.Pos[n+k] = k
+		}
+	}
+	S.NumBase = len(S.Etas)
+
+	// Basic values from B xB = -(sum of the nonbasic columns times their values)
+	Rhs := make([]float64, m)
+	for v := 0; v < n+m; v++ {
+		if S.Pos[v] >= 0 || S.X[v] == 0.0 {
+			continue
+		}
+		if v >= n {
+			Rhs[v-n] += S.X[v]
+			continue
+		}
+		for p := lp.LP.ColStart[v]; p < lp.LP.ColStart[v+1]; p++ {
+			if k := S.RowMap[lp.LP.RowIdx[p]]; k >= 0 {
+				Rhs[k] -= lp.LP.ColVal[p] * S.X[v]
+			}
+		}
+	}
+	S.ftran(Rhs)
+	for k := 0; k < m; k++ {
+		S.X[S.Basis[k]] = Rhs[k]
+	}
+}
+
+//=======================================================================================
+// The column of variable v (a structural restricted to the included rows, or -e for a logical)
+func (S *simplexLP) column(v int, Col []float64) {
+	for k := range Col {
+		Col[k] = 0.0
+	}
+	if v >= S.n {
+		Col[v-S.n] = -1.0
+		return
+	}
+	for p := lp.LP.ColStart[v]; p < lp.LP.ColStart[v+1]; p++ {
+		if k := S.RowMap[lp.LP.RowIdx[p]]; k >= 0 {
+			Col[k] += lp.LP.ColVal[p]
+		}
+	}
+}
+
+//=======================================================================================
+// Overwrites v with B^-1 v
+func (S *simplexLP) ftran(v []float64) {
+	for k := range v {
+		v[k] = -v[k]
+	}
+	for e := range S.Etas {
+		Eta := &S.Etas[e]
+		xr := v[Eta.Row] / Eta.Piv
+		v[Eta.Row] = xr
+		if xr == 0.0 {
+			continue
+		}
+		for p, k := range Eta.Idx {
+			v[k] -= Eta.Val[p] * xr
+		}
+	}
+}
+
+//=======================================================================================
+// Overwrites y with y B^-1
+func (S *simplexLP) btran(y []float64) {
+	for e := len(S.Etas) - 1; e >= 0; e-- {
+		Eta := &S.Etas[e]
+		s := y[Eta.Row]
+		for p, k := range Eta.Idx {
+			s -= Eta.Val[p] * y[k]
+		}
+		y[Eta.Row] = s / Eta.Piv
+	}
+	for k := range y {
+		y[k] = -y[k]
+	}
+}
+
+//=======================================================================================
+// Appends the eta vector for pivoting the FTRAN'd column Pivot into position r
+func (S *simplexLP) addEta(r int, Pivot []float64) {
+	Eta := etaVec{Row: r, Piv: Pivot[r]}
+	for k, a := range Pivot {
+		if k != r && math.Abs(a) > 1.0e-13 {
+			Eta.Idx = append(Eta.Idx, k)
+			Eta.Val = append(Eta.Val, a)
+		}
+	}
+	S.Etas = append(S.Etas, Eta)
+}
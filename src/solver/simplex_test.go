@@ -0,0 +1,71 @@
+package solver
+
+import (
+	"lp"
+	"math"
+	"testing"
+)
+
+//=======================================================================================
+// Builds min -x - y subject to x + y <= Cap and x - y >= -2, with x and y in [0, 3]
+func buildSimplexModel(t *testing.T, Cap float64) {
+	t.Helper()
+	lp.NewModel("SIMPLEXTEST", 1.0e10, 1.0e-6)
+	x, _ := lp.AddColumn("X", lp.ColR, 0.0, 3.0)
+	y, _ := lp.AddColumn("Y", lp.ColR, 0.0, 3.0)
+	lp.SetObjective([]int{x, y}, []float64{-1.0, -1.0})
+	Sum, _ := lp.AddRow("SUM", lp.RowL, Cap, 0.0)
+	lp.SetCoefficient(Sum, x, 1.0)
+	lp.SetCoefficient(Sum, y, 1.0)
+	Diff, _ := lp.AddRow("DIFF", lp.RowG, -2.0, 0.0)
+	lp.SetCoefficient(Diff, x, 1.0)
+	lp.SetCoefficient(Diff, y, -1.0)
+	if lp.EndModel() > 0 {
+		t.Fatal("EndModel failed")
+	}
+	SetTolerances(1.0e10, 1.0e-6)
+	PrintLevel = 0
+}
+
+//=======================================================================================
+func TestSimplexOptimal(t *testing.T) {
+	buildSimplexModel(t, 4.0)
+	Result, Status := Simplex(SIMPLEXOPTS{Optimize: true})
+	if Status != 0 {
+		t.Fatalf("status %d, want 0 (optimal)", Status)
+	}
+	if math.Abs(Result.Objective+4.0) > 1.0e-6 {
+		t.Errorf("objective %v, want -4", Result.Objective)
+	}
+	if _, NINF, _, _, _, _, _ := TestPoint(Result.Point); NINF > 0 {
+		t.Errorf("point %v violates %d rows or bounds", Result.Point, NINF)
+	}
+}
+
+//=======================================================================================
+func TestSimplexInfeasible(t *testing.T) {
+	buildSimplexModel(t, -1.0) // x + y <= -1 with x, y >= 0
+	Result, Status := Simplex(SIMPLEXOPTS{})
+	if Status != 1 {
+		t.Fatalf("status %d, want 1 (infeasible)", Status)
+	}
+	if Result.SumInf <= 0.0 {
+		t.Errorf("sum of infeasibilities %v, want > 0", Result.SumInf)
+	}
+}
+
+//=======================================================================================
+// A warm start on which both rows are tight puts structurals in the basis in their place
+func TestSimplexCrash(t *testing.T) {
+	buildSimplexModel(t, 4.0)
+	Result, Status := Simplex(SIMPLEXOPTS{Start: []float64{1.0, 3.0}, Optimize: true})
+	if Status != 0 {
+		t.Fatalf("status %d, want 0 (optimal)", Status)
+	}
+	if Result.NumCrash != 2 {
+		t.Errorf("%d crash columns, want 2", Result.NumCrash)
+	}
+	if math.Abs(Result.Objective+4.0) > 1.0e-6 {
+		t.Errorf("objective %v, want -4", Result.Objective)
+	}
+}
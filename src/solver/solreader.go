@@ -0,0 +1,254 @@
+package solver
+
+// Reads a point from a solution file written by this program or by another solver, matching
+// columns by name against the model that is currently read in. Recognized formats:
+//   text, json: written by WriteSolution
+//   cplex:      CPLEX XML .sol (the first solution if there are several)
+//   highs:      HiGHS solution file ("# Columns n" followed by name value lines)
+//   glpk:       GLPK raw solution file (glpsol -w), columns matched by position
+//   gurobi:     Gurobi .sol, and any other file of "name value" lines with # comments
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"lp"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//=======================================================================================
+// Sets the tolerances used by GetViolation, TestPoint and GetSolution without calling Solve
+func SetTolerances(plinfyIn float64, featolIn float64) {
+	plinfy = plinfyIn
+	featol = featolIn
+}
+
+//=======================================================================================
+// Works out the format of a solution file from its contents
+func SolutionFormat(Contents []byte) string {
+
+	Text := strings.TrimSpace(string(Contents))
+	switch {
+	case strings.HasPrefix(Text, "{"):
+		return "json"
+	case strings.HasPrefix(Text, "<"):
+		return "cplex"
+	case strings.HasPrefix(Text, "Model:"):
+		return "text"
+	case strings.HasPrefix(Text, "Model status") || strings.Contains(Text, "\n# Columns "):
+		return "highs"
+	case strings.HasPrefix(Text, "c ") || strings.HasPrefix(Text, "s "):
+		return "glpk"
+	}
+	return "gurobi"
+}
+
+//=======================================================================================
+// Reads a point for the current model from a solution file. Columns that the file does not
+// mention are set to zero, since several solvers leave out zero values.
+// Status: 0(success), 1(cannot read the file), 2(badly formed file)
+func ReadSolution(FileName string) (Point []float64, Format string, Status int) {
+
+	var Values map[string]float64
+
+	Contents, err := os.ReadFile(FileName)
+	if err != nil {
+		fmt.Println("Error: cannot read solution file", FileName, ":", err)
+		return nil, "", 1
+	}
+	Format = SolutionFormat(Contents)
+	Point = make([]float64, lp.LP.NumCols)
+
+	switch Format {
+	case "json":
+		var Sol SOLUTION
+		if err = json.Unmarshal(Contents, &Sol); err != nil {
+			fmt.Println("Error: badly formed JSON solution file", FileName, ":", err)
+			return nil, Format, 2
+		}
+		Values = make(map[string]float64)
+		for _, Col := range Sol.Columns {
+			Values[Col.Name] = Col.Value
+		}
+	case "cplex":
+		Values, Status = readCPLEXSolution(Contents)
+	case "glpk":
+		Status = readGLPKSolution(Contents, Point)
+	default:
+		Values, Status = readNameValueSolution(Contents, Format)
+	}
+	if Status > 0 {
+		fmt.Println("Error: badly formed", Format, "solution file", FileName)
+		return nil, Format, 2
+	}
+	if Values == nil {
+		return Point, Format, 0
+	}
+
+	// Match the values to the columns by name
+	NumMissing := 0
+	for j := 0; j < lp.LP.NumCols; j++ {
+		Value, ok := Values[lp.LP.Cols[j].Name]
+		if !ok {
+			NumMissing++
+			continue
+		}
+		Point[j] = Value
+		delete(Values, lp.LP.Cols[j].Name)
+	}
+	if NumMissing > 0 {
+		fmt.Println("Warning:", NumMissing, "columns are not in the solution file; they are set to zero.")
+	}
+	if len(Values) > 0 {
+		fmt.Println("Warning:", len(Values), "names in the solution file are not columns of the model; they are ignored.")
+	}
+	return Point, Format, 0
+}
+
+//=======================================================================================
+// Our own text format (COLUMNS section), HiGHS ("# Columns n" section) and Gurobi/generic
+// name value lines.
+// Status: 0(success), 2(bad value)
+func readNameValueSolution(Contents []byte, Format string) (Values map[string]float64, Status int) {
+
+	var InColumns bool
+
+	Values = make(map[string]float64)
+	Scanner := bufio.NewScanner(bytes.NewReader(Contents))
+	Scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	NumLeft := -1 // HiGHS: number of column lines still to read
+	for Scanner.Scan() {
+		Line := strings.TrimSpace(Scanner.Text())
+		Token := strings.Fields(Line)
+		switch Format {
+		case "text":
+			if Line == "COLUMNS" {
+				InColumns = true
+				Scanner.Scan() // heading line
+				continue
+			}
+			if Line == "" || Line == "ROWS" {
+				InColumns = false
+			}
+		case "highs":
+			if len(Token) == 3 && Token[0] == "#" && Token[1] == "Columns" {
+				n, err := strconv.Atoi(Token[2])
+				if err != nil {
+					return nil, 2
+				}
+				NumLeft = n
+				InColumns = NumLeft > 0
+				continue
+			}
+		default:
+			if Line == "" || strings.HasPrefix(Line, "#") {
+				continue
+			}
+			InColumns = true
+		}
+		if !InColumns {
+			continue
+		}
+		if len(Token) < 2 {
+			return nil, 2
+		}
+		Value, err := strconv.ParseFloat(Token[1], 64)
+		if err != nil {
+			return nil, 2
+		}
+		Values[Token[0]] = Value
+		if Format == "highs" {
+			NumLeft--
+			if NumLeft == 0 {
+				// Only the first (primal) columns section is wanted
+				return Values, 0
+			}
+		}
+	}
+	if Scanner.Err() != nil {
+		return nil, 2
+	}
+	return Values, 0
+}
+
+//=======================================================================================
+// CPLEX XML solution file. A file written with several solutions wraps them in
+// CPLEXSolutions; the first one is used.
+// Status: 0(success), 2(badly formed)
+func readCPLEXSolution(Contents []byte) (Values map[string]float64, Status int) {
+
+	type VARIABLE struct {
+		Name  string  `xml:"name,attr"`
+		Value float64 `xml:"value,attr"`
+	}
+	type CPLEXSOLUTION struct {
+		Variables []VARIABLE `xml:"variables>variable"`
+	}
+	var Sol CPLEXSOLUTION
+
+	Decoder := xml.NewDecoder(bytes.NewReader(Contents))
+	for {
+		Tok, err := Decoder.Token()
+		if err != nil {
+			return nil, 2
+		}
+		Start, ok := Tok.(xml.StartElement)
+		if !ok || Start.Name.Local != "CPLEXSolution" {
+			continue
+		}
+		if err = Decoder.DecodeElement(&Sol, &Start); err != nil {
+			return nil, 2
+		}
+		break
+	}
+	Values = make(map[string]float64)
+	for _, Var := range Sol.Variables {
+		Values[Var.Name] = Var.Value
+	}
+	return Values, 0
+}
+
+//=======================================================================================
+// GLPK raw solution file: a "s bas|ipt|mip ..." line then "j col ..." lines with the column
+// value in the position for that kind of solution. Columns are numbered from 1 in model order.
+// Status: 0(success), 2(badly formed)
+func readGLPKSolution(Contents []byte, Point []float64) (Status int) {
+
+	var Kind string
+
+	Scanner := bufio.NewScanner(bytes.NewReader(Contents))
+	for Scanner.Scan() {
+		Token := strings.Fields(Scanner.Text())
+		if len(Token) < 2 {
+			continue
+		}
+		switch Token[0] {
+		case "s":
+			Kind = Token[1]
+		case "j":
+			Pos := 2 // ipt: j col prim dual; mip: j col val
+			if Kind == "bas" {
+				Pos = 3 // j col stat prim dual
+			}
+			if len(Token) <= Pos {
+				return 2
+			}
+			j, err := strconv.Atoi(Token[1])
+			if err != nil || j < 1 || j > len(Point) {
+				return 2
+			}
+			Point[j-1], err = strconv.ParseFloat(Token[Pos], 64)
+			if err != nil {
+				return 2
+			}
+		}
+	}
+	if Kind == "" {
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package solver
+
+import (
+	"lp"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+//=======================================================================================
+// Builds the columns X, Y and Z in [-10, 10] and the row R: X + Y + Z <= 5
+func buildSolutionModel(t *testing.T) {
+	t.Helper()
+	lp.NewModel("SOLTEST", 1.0e10, 1.0e-6)
+	R, _ := lp.AddRow("R", lp.RowL, 5.0, 0.0)
+	for _, Name := range []string{"X", "Y", "Z"} {
+		icol, _ := lp.AddColumn(Name, lp.ColR, -10.0, 10.0)
+		lp.SetCoefficient(R, icol, 1.0)
+	}
+	if lp.EndModel() > 0 {
+		t.Fatal("EndModel failed")
+	}
+	SetTolerances(1.0e10, 1.0e-6)
+	PrintLevel = 0
+}
+
+//=======================================================================================
+// Every format gives X = 1.5, Y = 0 (left out where the format allows) and Z = -2
+func TestReadSolution(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Contents   string
+		WantFormat string
+		WantStatus int
+	}{
+		{"json", `{"model":"SOLTEST","columns":[{"name":"X","value":1.5},{"name":"Z","value":-2}]}`, "json", 0},
+		{"text", "Model: SOLTEST\nStatus: feasible\n\nCOLUMNS\nName Value Lower Upper Status\n" +
+			"X 1.5 -10 10 between\nZ -2 -10 10 between\n\nROWS\nName Type Activity Lower Upper Violation Status\n" +
+			"R L -0.5 -inf 5 0 slack\n", "text", 0},
+		{"highs", "Model status        : Optimal\n\n# Primal solution values\nFeasible\nObjective 0\n" +
+			"# Columns 3\nX 1.5\nY 0\nZ -2\n# Rows 1\nR -0.5\n\n# Dual solution values\nFeasible\n# Columns 3\nX 7\nY 7\nZ 7\n", "highs", 0},
+		{"gurobi", "# Objective value = 0\nX 1.5\nZ -2\n", "gurobi", 0},
+		{"cplex", `<?xml version = "1.0" encoding="UTF-8" standalone="yes"?>
+<CPLEXSolution version="1.2">
+ <header problemName="SOLTEST"/>
+ <variables>
+  <variable name="X" index="0" value="1.5"/>
+  <variable name="Z" index="2" value="-2"/>
+ </variables>
+</CPLEXSolution>
+`, "cplex", 0},
+		{"cplex with several solutions", `<?xml version = "1.0" encoding="UTF-8" standalone="yes"?>
+<CPLEXSolutions version="1.2">
+ <CPLEXSolution version="1.2">
+  <variables>
+   <variable name="X" index="0" value="1.5"/>
+   <variable name="Z" index="2" value="-2"/>
+  </variables>
+ </CPLEXSolution>
+ <CPLEXSolution version="1.2">
+  <variables>
+   <variable name="X" index="0" value="9"/>
+  </variables>
+ </CPLEXSolution>
+</CPLEXSolutions>
+`, "cplex", 0},
+		{"glpk mip", "c Problem: SOLTEST\ns mip 1 3 o 0\ni 1 -0.5\nj 1 1.5\nj 2 0\nj 3 -2\ne o f\n", "glpk", 0},
+		{"glpk basic", "c Problem: SOLTEST\ns bas 1 3 f f 0\ni 1 b -0.5 0\nj 1 b 1.5 0\nj 2 b 0 0\nj 3 b -2 0\ne o f\n", "glpk", 0},
+		{"glpk interior point", "s ipt 1 3 f 0\ni 1 -0.5 0\nj 1 1.5 0\nj 2 0 0\nj 3 -2 0\ne o f\n", "glpk", 0},
+		{"bad value", "X 1.5\nZ minus-two\n", "gurobi", 2},
+		{"glpk column out of range", "s mip 1 3 o 0\nj 4 1\n", "glpk", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			buildSolutionModel(t)
+			FileName := filepath.Join(t.TempDir(), "model.sol")
+			if err := os.WriteFile(FileName, []byte(tt.Contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			Point, Format, Status := ReadSolution(FileName)
+			if Format != tt.WantFormat || Status != tt.WantStatus {
+				t.Fatalf("format %s and status %d, want %s and %d", Format, Status, tt.WantFormat, tt.WantStatus)
+			}
+			if Status == 0 && !reflect.DeepEqual(Point, []float64{1.5, 0.0, -2.0}) {
+				t.Errorf("point %v, want [1.5 0 -2]", Point)
+			}
+		})
+	}
+}
+
+//=======================================================================================
+// A solution file written by WriteSolution reads back as the same point
+func TestSolutionRoundTrip(t *testing.T) {
+	for _, Format := range []string{"json", "text"} {
+		t.Run(Format, func(t *testing.T) {
+			buildSolutionModel(t)
+			Want := []float64{1.5, 0.1, -2.0 / 3.0}
+			FileName := filepath.Join(t.TempDir(), "model.sol")
+			if WriteSolution(FileName, Want, Format) > 0 {
+				t.Fatal("WriteSolution failed")
+			}
+			Point, GotFormat, Status := ReadSolution(FileName)
+			if Status != 0 || GotFormat != Format {
+				t.Fatalf("format %s and status %d", GotFormat, Status)
+			}
+			if !reflect.DeepEqual(Point, Want) {
+				t.Errorf("point %v, want %v", Point, Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package solver
+
+// Writes a point as a solution file: every column with its value and bounds, and every row
+// with its activity, bounds, violation and status. Plain text for reading, JSON for other tools.
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"lp"
+	"math"
+	"os"
+	"strings"
+)
+
+// A column in a solution file. Missing bounds are infinite.
+type SOLCOL struct {
+	Name    string   `json:"name"`
+	Value   float64  `json:"value"`
+	Lower   *float64 `json:"lower,omitempty"`
+	Upper   *float64 `json:"upper,omitempty"`
+	Integer bool     `json:"integer,omitempty"`
+	Semi    bool     `json:"semi,omitempty"` // semi-continuous or semi-integer: 0 or between the bounds
+	Status  string   `json:"status"`         // lower, upper, fixed, between, free, violated, fractional (integer column), off (semi column at 0)
+}
+
+// A row in a solution file. Missing bounds are infinite.
+type SOLROW struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"` // MPS row type: N, G, L, E, R, or C for a custom constraint
+	Activity  float64  `json:"activity"`
+	Lower     *float64 `json:"lower,omitempty"`
+	Upper     *float64 `json:"upper,omitempty"`
+	Violation float64  `json:"violation"` // signed as in GetViolation: RHS - activity for the violated side, 0 if satisfied
+	Status    string   `json:"status"`    // tight, slack, violated, free (nonbinding row), error (could not evaluate)
+}
+
+type SOLUTION struct {
+	Model     string   `json:"model"`
+	Feasible  bool     `json:"feasible"`
+	NINF      int      `json:"ninf"`
+	SINF      float64  `json:"sinf"`
+	MaxViol   float64  `json:"maxviol"`
+	Objective *float64 `json:"objective,omitempty"` // activity of the objective row, if there is one
+	Featol    float64  `json:"featol"`
+	TolMode   string   `json:"tolmode"`       // tolerance mode used for the statuses
+	TolModes  []string `json:"feasiblemodes"` // tolerance modes under which the point is feasible
+	MIPMode   bool     `json:"mip"`           // integrality counted in NINF and Feasible
+	NumInt    int      `json:"intcols"`       // number of integer columns
+	NumFrac   int      `json:"numfrac"`       // integer columns more than featol from an integer
+	SumFrac   float64  `json:"sumfrac"`       // total distance of those columns to the nearest integers
+	MaxFrac   float64  `json:"maxfrac"`       // largest distance of an integer column to the nearest integer
+	FracCols  []string `json:"fraccols,omitempty"`
+	NumSOS    int      `json:"sossets,omitempty"`     // number of special ordered sets
+	SOSViol   []string `json:"sosviolated,omitempty"` // names of the violated sets
+	Columns   []SOLCOL `json:"columns"`
+	Rows      []SOLROW `json:"rows"`
+	Custom    []SOLROW `json:"constraints,omitempty"` // custom constraints from Go code, type C
+}
+
+//=======================================================================================
+// Evaluates the point against the model that is currently read in and fills in a SOLUTION.
+// Uses the tolerances of the last call to Solve (or SetTolerances).
+func GetSolution(Point []float64) (Sol SOLUTION) {
+
+	Sol.Model = lp.LP.Name
+	Sol.Featol = featol
+	Sol.TolMode = TolMode.String()
+	Sol.TolModes = SatisfiedTolModes(Point)
+	_, Sol.NINF, _, _, Sol.SINF, Sol.MaxViol, _ = TestPoint(Point)
+	Sol.Feasible = Sol.NINF == 0
+	Sol.MIPMode = mipActive()
+	Frac := GetFractionality(Point)
+	Sol.NumInt, Sol.NumFrac, Sol.SumFrac, Sol.MaxFrac, Sol.FracCols = Frac.NumInt, Frac.NumFrac, Frac.SumFrac, Frac.MaxFrac, Frac.Columns
+	Sol.NumSOS = len(lp.LP.SOS)
+	for iset := range lp.LP.SOS {
+		if Violation, _, _ := SOSViolation(iset, Point); Violation != 0.0 {
+			Sol.SOSViol = append(Sol.SOSViol, lp.LP.SOS[iset].Name)
+		}
+	}
+	if lp.LP.ObjRow >= 0 {
+		Obj, _ := lp.ConBodyValue(lp.LP.ObjRow, Point)
+		Sol.Objective = &Obj
+	}
+
+	Sol.Columns = make([]SOLCOL, lp.LP.NumCols)
+	for j := 0; j < lp.LP.NumCols; j++ {
+		Col := &lp.LP.Cols[j]
+		SolCol := &Sol.Columns[j]
+		SolCol.Name = Col.Name
+		SolCol.Value = Point[j]
+		SolCol.Lower = finiteOrNil(Col.BndLo)
+		SolCol.Upper = finiteOrNil(Col.BndUp)
+		SolCol.Integer = Col.Type.IsInteger()
+		SolCol.Semi = Col.Type.IsSemi()
+		AtLo := Col.BndLo > -plinfy && math.Abs(Point[j]-Col.BndLo) <= featol
+		AtUp := Col.BndUp < plinfy && math.Abs(Point[j]-Col.BndUp) <= featol
+		switch {
+		case BoundViolation(j, Point[j]) != 0.0:
+			SolCol.Status = "violated"
+		case SolCol.Integer && math.Abs(Point[j]-math.Floor(Point[j]+0.5)) > featol:
+			SolCol.Status = "fractional"
+		case SolCol.Semi && math.Abs(Point[j]) <= featol && !AtLo && !AtUp:
+			SolCol.Status = "off"
+		case AtLo && AtUp:
+			SolCol.Status = "fixed"
+		case AtLo:
+			SolCol.Status = "lower"
+		case AtUp:
+			SolCol.Status = "upper"
+		case Col.BndLo <= -plinfy && Col.BndUp >= plinfy:
+			SolCol.Status = "free"
+		default:
+			SolCol.Status = "between"
+		}
+	}
+
+	Sol.Rows = make([]SOLROW, lp.LP.NumRows)
+	for i := 0; i < lp.LP.NumRows; i++ {
+		Row := &lp.LP.Rows[i]
+		SolRow := &Sol.Rows[i]
+		SolRow.Name = Row.Name
+		SolRow.Type = Row.Type.String()
+		SolRow.Activity, _ = lp.ConBodyValue(i, Point)
+		if Row.Type == lp.RowN {
+			SolRow.Status = "free"
+			continue
+		}
+		// Only the side(s) that GetViolation checks: the reader leaves the other side of G and L rows at 0
+		if Row.Type != lp.RowL {
+			SolRow.Lower = finiteOrNil(Row.RHSlo)
+		}
+		if Row.Type != lp.RowG {
+			SolRow.Upper = finiteOrNil(Row.RHSup)
+		}
+		FVStatus, ViolStatus, Violation := GetViolation(i, Point)
+		SolRow.Violation = Violation
+		switch {
+		case FVStatus > 0:
+			SolRow.Status = "error"
+		case ViolStatus == 0:
+			SolRow.Status = "violated"
+		case ViolStatus == 2:
+			SolRow.Status = "tight"
+		default:
+			SolRow.Status = "slack"
+		}
+	}
+
+	for ic, c := range Constraints {
+		var SolRow SOLROW
+		SolRow.Name = c.Name()
+		SolRow.Type = "C"
+		Lo, Up := c.Bounds()
+		SolRow.Lower, SolRow.Upper = finiteOrNil(Lo), finiteOrNil(Up)
+		CStatus, Violation, Value := ConstraintViolation(ic, Point, TolMode)
+		SolRow.Activity, SolRow.Violation = Value, Violation
+		switch {
+		case CStatus > 0:
+			SolRow.Status = "error"
+		case Violation != 0.0:
+			SolRow.Status = "violated"
+		case (Lo > -plinfy && Value-Lo <= featol) || (Up < plinfy && Up-Value <= featol):
+			SolRow.Status = "tight"
+		default:
+			SolRow.Status = "slack"
+		}
+		Sol.Custom = append(Sol.Custom, SolRow)
+	}
+	return Sol
+}
+
+//=======================================================================================
+// Writes the point to a solution file.
+// Format: "text" or "json".
+// Status: 0(success), 1(cannot write the file), 2(unknown format or wrong point length)
+func WriteSolution(FileName string, Point []float64, Format string) (Status int) {
+
+	if len(Point) != lp.LP.NumCols {
+		fmt.Println("Error: point has", len(Point), "values but the model has", lp.LP.NumCols, "columns. No solution written.")
+		return 2
+	}
+	if Format != "text" && Format != "json" {
+		fmt.Println("Error: unknown solution format", Format)
+		return 2
+	}
+	Sol := GetSolution(Point)
+
+	File, err := os.Create(FileName)
+	if err != nil {
+		fmt.Println("Error: cannot create solution file", FileName, ":", err)
+		return 1
+	}
+	defer File.Close()
+	Writer := bufio.NewWriter(File)
+
+	if Format == "json" {
+		Encoder := json.NewEncoder(Writer)
+		Encoder.SetIndent("", " ")
+		err = Encoder.Encode(Sol)
+	} else {
+		writeSolutionText(Writer, Sol)
+	}
+	if err == nil {
+		err = Writer.Flush()
+	}
+	if err != nil {
+		fmt.Println("Error: cannot write solution file", FileName, ":", err)
+		return 1
+	}
+	return 0
+}
+
+//=======================================================================================
+func writeSolutionText(Writer *bufio.Writer, Sol SOLUTION) {
+
+	fmt.Fprintln(Writer, "Model:", Sol.Model)
+	if Sol.Feasible && Sol.NumFrac > 0 {
+		fmt.Fprintln(Writer, "Status: feasible for the LP relaxation only")
+	} else if Sol.Feasible {
+		fmt.Fprintln(Writer, "Status: feasible")
+	} else {
+		fmt.Fprintln(Writer, "Status: infeasible")
+	}
+	fmt.Fprintln(Writer, "NINF:", Sol.NINF)
+	fmt.Fprintln(Writer, "SINF:", Sol.SINF)
+	fmt.Fprintln(Writer, "MaxViol:", Sol.MaxViol)
+	if Sol.Objective != nil {
+		fmt.Fprintln(Writer, "Objective:", *Sol.Objective)
+	}
+	fmt.Fprintln(Writer, "Featol:", Sol.Featol)
+	fmt.Fprintln(Writer, "TolMode:", Sol.TolMode)
+	fmt.Fprintln(Writer, "FeasibleModes:", strings.Join(Sol.TolModes, " "))
+	if Sol.NumInt > 0 {
+		fmt.Fprintln(Writer, "MIPMode:", Sol.MIPMode)
+		fmt.Fprintln(Writer, "IntegerColumns:", Sol.NumInt)
+		fmt.Fprintln(Writer, "FractionalColumns:", Sol.NumFrac)
+		fmt.Fprintln(Writer, "SumFractionality:", Sol.SumFrac)
+		fmt.Fprintln(Writer, "MaxFractionality:", Sol.MaxFrac)
+	}
+	if Sol.NumSOS > 0 {
+		fmt.Fprintln(Writer, "SOSSets:", Sol.NumSOS)
+		fmt.Fprintln(Writer, "ViolatedSOS:", strings.Join(Sol.SOSViol, " "))
+	}
+
+	fmt.Fprintln(Writer)
+	fmt.Fprintln(Writer, "COLUMNS")
+	fmt.Fprintf(Writer, "%-16s %24s %24s %24s  %s\n", "Name", "Value", "Lower", "Upper", "Status")
+	for _, Col := range Sol.Columns {
+		fmt.Fprintf(Writer, "%-16s %24.16g %24s %24s  %s\n", Col.Name, Col.Value, boundText(Col.Lower, "-inf"), boundText(Col.Upper, "inf"), Col.Status)
+	}
+
+	fmt.Fprintln(Writer)
+	fmt.Fprintln(Writer, "ROWS")
+	fmt.Fprintf(Writer, "%-16s %-4s %24s %24s %24s %14s  %s\n", "Name", "Type", "Activity", "Lower", "Upper", "Violation", "Status")
+	for _, Row := range Sol.Rows {
+		fmt.Fprintf(Writer, "%-16s %-4s %24.16g %24s %24s %14.6g  %s\n", Row.Name, Row.Type, Row.Activity,
+			boundText(Row.Lower, "-inf"), boundText(Row.Upper, "inf"), Row.Violation, Row.Status)
+	}
+	if len(Sol.Custom) == 0 {
+		return
+	}
+
+	fmt.Fprintln(Writer)
+	fmt.Fprintln(Writer, "CONSTRAINTS")
+	fmt.Fprintf(Writer, "%-16s %-4s %24s %24s %24s %14s  %s\n", "Name", "Type", "Value", "Lower", "Upper", "Violation", "Status")
+	for _, Row := range Sol.Custom {
+		fmt.Fprintf(Writer, "%-16s %-4s %24.16g %24s %24s %14.6g  %s\n", Row.Name, Row.Type, Row.Activity,
+			boundText(Row.Lower, "-inf"), boundText(Row.Upper, "inf"), Row.Violation, Row.Status)
+	}
+}
+
+//=======================================================================================
+// Prints the feasibility summary for a point as TestPoint sees it, then every violated row
+// and bound by name.
+func PrintViolations(Point []float64) {
+
+	Status, NINF, NumSat, NumTight, SINF, MaxViol, AvgViol := TestPoint(Point)
+	if Status > 0 {
+		fmt.Println("Warning: some rows could not be evaluated at the point.")
+	}
+	fmt.Println("  ", NINF, "NINF (violated constraints/bounds)")
+	fmt.Println("  ", NumSat, "Satisfied constraints/bounds")
+	fmt.Println("    ", NumTight, "Tight constraints/bounds")
+	fmt.Println("  ", SINF, "SINF (sum of violations)")
+	fmt.Println("    ", MaxViol, "Maximum violation")
+	fmt.Println("    ", AvgViol, "Average violation (for violated constraints/bounds)")
+	fmt.Println("   Feasible under tolerance modes:", strings.Join(SatisfiedTolModes(Point), " "))
+	Sol := GetSolution(Point)
+	if Sol.NumInt > 0 {
+		fmt.Println("  ", Sol.NumFrac, "of", Sol.NumInt, "integer columns fractional ( total", Sol.SumFrac, ", max", Sol.MaxFrac, ")")
+		if Sol.NumFrac > 0 && !Sol.MIPMode {
+			fmt.Println("   Integrality is not counted in NINF: use the MIP mode to enforce it")
+		}
+	}
+	if NINF == 0 && Sol.NumFrac == 0 {
+		return
+	}
+
+	// Each section is printed only if it has something in it
+	fmt.Println()
+	var ViolRows []SOLROW
+	for _, Row := range append(Sol.Rows, Sol.Custom...) {
+		if Row.Status == "violated" || Row.Status == "error" {
+			ViolRows = append(ViolRows, Row)
+		}
+	}
+	if len(ViolRows) > 0 {
+		fmt.Println("Violated rows:")
+		for _, Row := range ViolRows {
+			fmt.Printf("  %-16s %s  activity %.12g  bounds [%s, %s]  violation %.6g\n", Row.Name, Row.Type, Row.Activity,
+				boundText(Row.Lower, "-inf"), boundText(Row.Upper, "inf"), Row.Violation)
+		}
+	}
+	var ViolCols []SOLCOL
+	for _, Col := range Sol.Columns {
+		if Col.Status == "violated" {
+			ViolCols = append(ViolCols, Col)
+		}
+	}
+	if len(ViolCols) > 0 {
+		fmt.Println("Violated bounds:")
+		for _, Col := range ViolCols {
+			if Col.Lower != nil && Col.Value < *Col.Lower {
+				fmt.Printf("  %-16s value %.12g  below lower bound %.12g by %.6g\n", Col.Name, Col.Value, *Col.Lower, *Col.Lower-Col.Value)
+			} else if Col.Upper != nil {
+				fmt.Printf("  %-16s value %.12g  above upper bound %.12g by %.6g\n", Col.Name, Col.Value, *Col.Upper, Col.Value-*Col.Upper)
+			}
+		}
+	}
+	if len(Sol.SOSViol) > 0 {
+		fmt.Println("Violated special ordered sets:")
+		for iset := range lp.LP.SOS {
+			Set := &lp.LP.SOS[iset]
+			Violation, _, _ := SOSViolation(iset, Point)
+			if Violation == 0.0 {
+				continue
+			}
+			fmt.Printf("  %-16s S%d  violation %.6g  nonzero:", Set.Name, Set.Type, Violation)
+			for _, ivar := range Set.Cols {
+				if math.Abs(Point[ivar]) > featol {
+					fmt.Printf(" %s=%.6g", lp.LP.Cols[ivar].Name, Point[ivar])
+				}
+			}
+			fmt.Println()
+		}
+	}
+	if Sol.NumFrac > 0 {
+		// As counted in NumFrac: a column out of its bounds has the status "violated" but is listed here too
+		fmt.Println("Fractional integer columns:")
+		for _, Col := range Sol.Columns {
+			if !Col.Integer || math.Abs(Col.Value-math.Floor(Col.Value+0.5)) <= featol {
+				continue
+			}
+			if Col.Status == "violated" {
+				fmt.Printf("  %-16s value %.12g  (also out of bounds)\n", Col.Name, Col.Value)
+			} else {
+				fmt.Printf("  %-16s value %.12g\n", Col.Name, Col.Value)
+			}
+		}
+	}
+}
+
+//=======================================================================================
+// Bounds at or beyond plus infinity are left out of solution files
+func finiteOrNil(Bound float64) *float64 {
+	if Bound <= -plinfy || Bound >= plinfy {
+		return nil
+	}
+	return &Bound
+}
+
+//=======================================================================================
+func boundText(Bound *float64, Infinite string) string {
+	if Bound == nil {
+		return Infinite
+	}
+	return fmt.Sprintf("%.16g", *Bound)
+}
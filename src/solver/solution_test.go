@@ -0,0 +1,111 @@
+package solver
+
+import (
+	"bytes"
+	"io"
+	"lp"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//=======================================================================================
+// Builds the integer columns I1 in [0, 3] and I2 in [0, 10], the semi-continuous S in {0} +
+// [2, 5], the free F and the continuous C in [1, 4], all in the row R: their sum <= 100
+func buildReportModel(t *testing.T) {
+	t.Helper()
+	lp.NewModel("REPORTTEST", 1.0e10, 1.0e-6)
+	R, _ := lp.AddRow("R", lp.RowL, 100.0, 0.0)
+	Cols := []struct {
+		Name   string
+		Type   lp.COLTYPE
+		Lo, Up float64
+	}{
+		{"I1", lp.ColI, 0.0, 3.0},
+		{"I2", lp.ColI, 0.0, 10.0},
+		{"S", lp.ColSC, 2.0, 5.0},
+		{"F", lp.ColR, -1.0e10, 1.0e10},
+		{"C", lp.ColR, 1.0, 4.0},
+	}
+	for _, Col := range Cols {
+		icol, _ := lp.AddColumn(Col.Name, Col.Type, Col.Lo, Col.Up)
+		lp.SetCoefficient(R, icol, 1.0)
+	}
+	if lp.EndModel() > 0 {
+		t.Fatal("EndModel failed")
+	}
+	SetTolerances(1.0e10, 1.0e-6)
+	PrintLevel = 0
+}
+
+//=======================================================================================
+// Runs f and returns what it printed
+func captureOutput(t *testing.T, f func()) string {
+	t.Helper()
+	Reader, Writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Stdout := os.Stdout
+	os.Stdout = Writer
+	Done := make(chan string)
+	go func() {
+		var Buf bytes.Buffer
+		io.Copy(&Buf, Reader)
+		Done <- Buf.String()
+	}()
+	f()
+	Writer.Close()
+	os.Stdout = Stdout
+	return <-Done
+}
+
+//=======================================================================================
+// An integer column that is both fractional and out of bounds has the status "violated", but
+// is counted and listed with the fractional columns all the same
+func TestSolutionFractionalColumns(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Point        []float64 // I1, I2, S, F, C
+		WantStatus   []string
+		WantFracCols []string
+	}{
+		{"integral", []float64{3.0, 0.0, 0.0, -7.5, 2.0},
+			[]string{"upper", "lower", "off", "free", "between"}, nil},
+		{"fractional", []float64{1.5, 10.0, 5.0, 0.0, 1.0},
+			[]string{"fractional", "upper", "upper", "free", "lower"}, []string{"I1"}},
+		{"fractional and out of bounds", []float64{3.5, 2.25, 1.0, 0.0, 4.5},
+			[]string{"violated", "fractional", "violated", "free", "violated"}, []string{"I1", "I2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			buildReportModel(t)
+			Sol := GetSolution(tt.Point)
+			var Status []string
+			for _, Col := range Sol.Columns {
+				Status = append(Status, Col.Status)
+			}
+			if !reflect.DeepEqual(Status, tt.WantStatus) {
+				t.Errorf("column statuses %v, want %v", Status, tt.WantStatus)
+			}
+			if Sol.NumFrac != len(tt.WantFracCols) || !reflect.DeepEqual(Sol.FracCols, tt.WantFracCols) {
+				t.Errorf("NumFrac %d, FracCols %v, want %v", Sol.NumFrac, Sol.FracCols, tt.WantFracCols)
+			}
+
+			Output := captureOutput(t, func() { PrintViolations(tt.Point) })
+			var Listed []string
+			if k := strings.Index(Output, "Fractional integer columns:\n"); k >= 0 {
+				for _, Line := range strings.Split(Output[k:], "\n")[1:] {
+					if !strings.HasPrefix(Line, "  ") {
+						break
+					}
+					Listed = append(Listed, strings.Fields(Line)[0])
+				}
+			}
+			if !reflect.DeepEqual(Listed, tt.WantFracCols) {
+				t.Errorf("PrintViolations lists %v as fractional, want %v", Listed, tt.WantFracCols)
+			}
+		})
+	}
+}
@@ -9,8 +9,8 @@ import (
 	"math/rand"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
-	//"sync"
 	//"os"
 )
 
@@ -25,7 +25,13 @@ var Beta float64       // Movement tolerance
 var MaxItns int        // Maximum number of iterations
 var Point []float64    // A point
 var FinalBox int       // Captures the last box commenced so it can be printed out
+var NumCCRuns int      // Number of CC runs completed in the last call to Solve
+var RandomSeed int64   // Seed for the random number generator. 0: seed from the clock
+var SeedUsed int64     // The seed actually used in the last call to Solve
+var TimeLimit time.Duration // Limit on the calculation time in Solve. 0: no limit
 var FinalPointType int // Captures the type of the final point.
+var MaxRounds int = 100 // Maximum number of rounds (sample boxes) in Solve
+var WarmBoxFrac float64 = 0.1 // Half-width of a warm-started sample box, as a fraction of max(1, |start value|)
 
 var MaxSwarmPts int        // Maximum number of points in a swarm
 var MaxPts int             // The number of points this time around. Equals NumSpecialPts or MaxSwarmPts
@@ -49,6 +55,8 @@ var NIncumbentSINF float64 // SINF at NINF incumbent point
 var NIncumbentSFD float64  // SFD at NINF incumbent point
 var NIncumbentNINF int     // NINF at NINF incumbent point
 
+var incumbentLock sync.Mutex // Serializes the incumbent updates made by the concurrent CC runs
+
 var SmallestNINF int // Smallest NINF encountered
 
 var BoxBndLo []float64 // Sample box lower bounds
@@ -63,6 +71,7 @@ var LinProjSucceeds, LinProjFails int // Number of successes and failures for au
 var LinProjFrac float64               // Average fractional augmentation successes.
 var QuadProjSucceeds, QuadProjFails int
 var QuadProjFrac float64
+var NumNumericalFails int // Number of CC runs and swarm points discarded because of a numerical problem (NaN or infinite values)
 
 // Structures needed for sorting the impact list
 type IMPACT struct {
@@ -72,10 +81,18 @@ type IMPACT struct {
 type BySum []IMPACT
 var ImpactList []int // Holds the constraint id number for row constraints sorted from most to least impact
 
+// A warm start for Solve: the points the first CC runs start from and the first sample box
+type WARMSTART struct {
+	Points [][]float64 // Start points, e.g. read from solution files. nil: none
+	BoxLo  []float64   // First sample box. nil: centred on the best start point
+	BoxUp  []float64
+}
+
 type POINTDATA struct {
 	Point []float64
 	SFD float64
 	NINF int
+	Status int // 0(normal), 1(numerical problem, so the point should be discarded)
 }
 
 //var IncumbentUp, IncumbentDown, IncumbentSame []int // When incumbent changes, the variable might go up, down, or stay the same. Count the changes.
@@ -88,13 +105,21 @@ type POINTDATA struct {
 //var MaxElsInCol int
 
 //=======================================================================================
-// Returns the violation (with sign) for a given constraint (but not bounds)
+// Returns the violation (with sign) for a given constraint (but not bounds), using the
+// tolerance mode TolMode of the feasibility oracle
 // FVStatus: 0:(success), 1:(numerical problem)
 // ViolStatus: 0:(violated), 1:(oversatisfied), 2:(tight within tolerance)
 func GetViolation(icon int, CCPoint []float64) (FVStatus int, ViolStatus int, Violation float64) {
+	return GetViolationMode(icon, CCPoint, TolMode)
+}
+
+//=======================================================================================
+// GetViolation under the given tolerance mode
+func GetViolationMode(icon int, CCPoint []float64, Mode TOLMODE) (FVStatus int, ViolStatus int, Violation float64) {
 
 	var BodyVal float64 = 0
 	var Status int
+	var TolLo, TolUp float64 // Tolerances on the lower and upper sides
 
 	//Check violation status
 	BodyVal, Status = lp.ConBodyValue(icon, CCPoint)
@@ -102,52 +127,60 @@ func GetViolation(icon int, CCPoint []float64) (FVStatus int, ViolStatus int, Vi
 		return 0, 1, 0.0
 	}
 	if Status == 2 { // Numerical problem
-		fmt.Println("Problem evaluating body of constraint", icon, ". Aborting solver.GetViolation.")
+		if PrintLevel > 0 {
+			fmt.Println("Problem evaluating body of constraint", icon, ". Aborting solver.GetViolation.")
+			lp.ReportBadBody(icon, CCPoint)
+		}
 		return 1, 0, 0.0
 	}
 	// Constraint body successfully evaluated. Now check for violation (sign is correct)
-	switch lp.LP.Rows[icon].Type {
-	case "G": // Greater than constraint
-		if BodyVal >= lp.LP.Rows[icon].RHSlo-featol {
+	Row := &lp.LP.Rows[icon]
+	switch Row.Type {
+	case lp.RowG: // Greater than constraint
+		TolLo = RowTolerance(icon, Row.RHSlo, Mode)
+		if BodyVal >= Row.RHSlo-TolLo {
 			Violation = 0.0
 			ViolStatus = 1
-			if BodyVal-lp.LP.Rows[icon].RHSlo <= featol {
+			if BodyVal-Row.RHSlo <= TolLo {
 				ViolStatus = 2
 			}
 		} else {
-			Violation = lp.LP.Rows[icon].RHSlo - BodyVal
+			Violation = Row.RHSlo - BodyVal
 			ViolStatus = 0
 		}
-	case "L": // Less than constraint
-		if BodyVal <= lp.LP.Rows[icon].RHSup+featol {
+	case lp.RowL: // Less than constraint
+		TolUp = RowTolerance(icon, Row.RHSup, Mode)
+		if BodyVal <= Row.RHSup+TolUp {
 			Violation = 0.0
 			ViolStatus = 1
-			if lp.LP.Rows[icon].RHSup-BodyVal <= featol {
+			if Row.RHSup-BodyVal <= TolUp {
 				ViolStatus = 2
 			}
 		} else {
-			Violation = lp.LP.Rows[icon].RHSup - BodyVal
+			Violation = Row.RHSup - BodyVal
 			ViolStatus = 0
 		}
-	case "E", "R": // equality or range constraint
+	case lp.RowE, lp.RowR: // equality or range constraint
+		TolLo = RowTolerance(icon, Row.RHSlo, Mode)
+		TolUp = RowTolerance(icon, Row.RHSup, Mode)
 		Violation = 0.0
 		ViolStatus = 1
-		if BodyVal <= lp.LP.Rows[icon].RHSlo-featol {
+		if BodyVal <= Row.RHSlo-TolLo {
 			// Violates lower bound
-			Violation = lp.LP.Rows[icon].RHSlo - BodyVal
+			Violation = Row.RHSlo - BodyVal
 			ViolStatus = 0
-		} else if BodyVal >= lp.LP.Rows[icon].RHSup+featol {
+		} else if BodyVal >= Row.RHSup+TolUp {
 			// Violates upper bound
-			Violation = lp.LP.Rows[icon].RHSup - BodyVal
+			Violation = Row.RHSup - BodyVal
 			ViolStatus = 0
 		}
 		if ViolStatus == 1 {
 			// Check whether it's tight to one of the bounds
-			if lp.LP.Rows[icon].Type == "E" {
+			if Row.Type == lp.RowE {
 				ViolStatus = 2 // It's an equality and satisfies both bounds, so it's tight
 			} else {
 				// It's a range constraint so you have to check whether it's tight to either RHS
-				if math.Abs(BodyVal-lp.LP.Rows[icon].RHSlo) <= featol || math.Abs(BodyVal-lp.LP.Rows[icon].RHSup) <= featol {
+				if math.Abs(BodyVal-Row.RHSlo) <= TolLo || math.Abs(BodyVal-Row.RHSup) <= TolUp {
 					ViolStatus = 2
 				}
 			}
@@ -1253,21 +1286,56 @@ func CCSimple(PointIn []float64, chPointData chan POINTDATA, PointID int) {
 	SumWeights := make([]float64, len(PointIn))      // Sum of the weights
 	CCPoint := make([]float64, len(PointIn))         // Constraint consensus point
 	CV := make([]float64, len(PointIn))              // Consensus Vector
+	var SumViolC []float64                           // Compensation for SumViol in the AccurateSums mode
+	if lp.AccurateSums {
+		SumViolC = make([]float64, len(PointIn))
+	}
 
 	FVMaxViol := make([]float64, len(PointIn))     // Captures the individual feasibility vector associated with the maximum LHS-RHS violation
 	FVMaxFVLength := make([]float64, len(PointIn)) // Captures the individual feasibility vector associated with the largest feasibility vector
 	var FVStatus int = 0
 	var ViolStatus int = 0
-	var ElNum int  // Element number
 	var ColNum int // Column number
-	var rhold, rhold1 float64
+	var rhold float64
 
 	var PointOut POINTDATA
 	PointOut.Point = make([]float64, lp.NumCols)
+	var Grad []float64 // Gradient of a quadratic row or custom constraint at the point
+	if lp.NumQRows > 0 || len(Constraints) > 0 {
+		Grad = make([]float64, lp.NumCols)
+	}
 
 	copy(CCPoint, PointIn)
-	
-	for itn:=0; itn<10; itn++ {
+
+	// MIP mode: CC repair alternates with rounding (see mip.go)
+	NumIts := 10
+	var Rounded, Unrounded []float64 // The point after the last rounding, and before it
+	var RoundRand *rand.Rand
+	if mipActive() {
+		NumIts = MIPCCIts
+		Rounded = make([]float64, len(PointIn))
+		Unrounded = make([]float64, len(PointIn))
+		// Seeded from the start point, which comes from the seeded generator in Solve, so that a
+		// run with a fixed RandomSeed is repeatable
+		RoundRand = rand.New(rand.NewSource(SeedUsed + int64(math.Float64bits(PointIn[0])) + int64(PointID)))
+	}
+
+	for itn:=0; itn<NumIts; itn++ {
+		if Rounded != nil && RoundEvery > 0 && itn%RoundEvery == 0 {
+			copy(Unrounded, CCPoint)
+			RoundPoint(CCPoint, RoundMode, RoundRand)
+			if itn > 0 && sameIntegers(CCPoint, Rounded) {
+				// The pump is cycling: round randomly instead
+				copy(CCPoint, Unrounded)
+				RoundPoint(CCPoint, RoundRandom, RoundRand)
+			}
+			copy(Rounded, CCPoint)
+		}
+		// Special ordered sets are repaired directly in the same rhythm, and in between their
+		// members outside the admissible ones have feasibility vectors pointing at 0
+		if len(lp.LP.SOS) > 0 && RoundEvery > 0 && itn%RoundEvery == 0 {
+			RepairSOS(CCPoint)
+		}
 		// Zero the accumulators
 		NINF = 0
 		SFD = 0.0
@@ -1275,6 +1343,9 @@ func CCSimple(PointIn []float64, chPointData chan POINTDATA, PointID int) {
 			NumViol[i] = 0
 			SumViol[i] = 0.0
 			CV[i] = 0.0
+			if SumViolC != nil {
+				SumViolC[i] = 0.0
+			}
 			FVMaxViol[i] = 0.0
 			FVMaxFVLength[i] = 0.0
 			SumWeightedViol[i] = 0.0
@@ -1286,68 +1357,125 @@ func CCSimple(PointIn []float64, chPointData chan POINTDATA, PointID int) {
 			// Get the feasibility vector, if there is one
 			FVStatus, ViolStatus, Violation = GetViolation(icon, CCPoint)
 			if FVStatus > 0 {
-				fmt.Println("Error evaluating feasibility status for constraint", icon, ". Skipping it.")
-				continue
+				// Numerical problem: discard this sample point
+				copy(PointOut.Point, CCPoint)
+				PointOut.Status = 1
+				chPointData <- PointOut
+				return
 			}
 			if ViolStatus > 0 {
 				// not violated, so skip
 				continue
 			}
-			// Check length of feasibility vector
-			rhold = 0.0 // Accumulates length of feasibility vector
-			for iel := 0; iel < lp.LP.Rows[icon].NumEl; iel++ {
-				ElNum = lp.LP.Rows[icon].ElList[iel]
-				ColNum = lp.Element[ElNum].Col
-				rhold1 = Violation * lp.Element[ElNum].Value / lp.LP.Rows[icon].GradVecLenSq
-				rhold = rhold + rhold1*rhold1
-			}
-			if rhold < Alpha*Alpha {
-				// Feasibility vector is too short so skip this constraint
+			if len(lp.LP.Rows[icon].QTerms) > 0 {
+				// A quadratic row: the feasibility vector follows the gradient at the point, the
+				// first-order step onto the constraint
+				NINF++
+				GradLenSq := lp.QuadGradient(icon, CCPoint, Grad)
+				if GradLenSq == 0.0 {
+					// A stationary point of the row body: no direction to move in
+					SFD = SFD + math.Abs(Violation)
+					continue
+				}
+				SFD = SFD + math.Abs(Violation)/math.Sqrt(GradLenSq)
+				for _, ColNum = range lp.LP.Rows[icon].QCols {
+					if Grad[ColNum] == 0.0 {
+						continue
+					}
+					NumViol[ColNum]++
+					compensatedAdd(SumViol, SumViolC, ColNum, Violation*Grad[ColNum]/GradLenSq)
+					SumWeightedViol[ColNum] = SumWeightedViol[ColNum] + Violation*Grad[ColNum]/GradLenSq*math.Abs(Violation)
+					SumWeights[ColNum] = SumWeights[ColNum] + math.Abs(Violation)
+				}
 				continue
 			}
-			FVLength = math.Sqrt(rhold)
+			FVLength = math.Abs(Violation) * lp.LP.Rows[icon].InvGradVecLen // Length of feasibility vector
 			SFD = SFD + FVLength
 	
 			// Constraint is violated
 			NINF++
 	
 			// Calculate the relevant elements of the feasibility vector
-			for iel := 0; iel < lp.LP.Rows[icon].NumEl; iel++ {
-				ElNum = lp.LP.Rows[icon].ElList[iel]
-				ColNum = lp.Element[ElNum].Col
+			for k := lp.LP.RowStart[icon]; k < lp.LP.RowStart[icon+1]; k++ {
+				ColNum = lp.LP.ColIdx[k]
 				NumViol[ColNum]++
-				SumViol[ColNum] = SumViol[ColNum] + Violation*lp.Element[ElNum].Value/lp.LP.Rows[icon].GradVecLenSq
-				SumWeightedViol[ColNum] = SumWeightedViol[ColNum] + Violation*lp.Element[ElNum].Value/lp.LP.Rows[icon].GradVecLenSq*math.Abs(Violation)
+				compensatedAdd(SumViol, SumViolC, ColNum, Violation*lp.LP.RowVal[k]*lp.LP.Rows[icon].InvGradVecLenSq)
+				SumWeightedViol[ColNum] = SumWeightedViol[ColNum] + Violation*lp.LP.RowVal[k]*lp.LP.Rows[icon].InvGradVecLenSq*math.Abs(Violation)
 				SumWeights[ColNum] = SumWeights[ColNum] + math.Abs(Violation)
 			}
 		}
 	
+		// Custom constraints: the feasibility vector follows the gradient, as for a quadratic row
+		for ic := range Constraints {
+			CStatus, Violation, _ := ConstraintViolation(ic, CCPoint, TolMode)
+			if CStatus > 0 {
+				// Numerical problem: discard this sample point
+				copy(PointOut.Point, CCPoint)
+				PointOut.Status = 1
+				chPointData <- PointOut
+				return
+			}
+			if Violation == 0.0 {
+				continue
+			}
+			NINF++
+			GradLenSq := constraintGradient(ic, CCPoint, Grad)
+			if GradLenSq == 0.0 {
+				SFD = SFD + math.Abs(Violation)
+				continue
+			}
+			SFD = SFD + math.Abs(Violation)/math.Sqrt(GradLenSq)
+			for ColNum = range Grad {
+				if Grad[ColNum] == 0.0 {
+					continue
+				}
+				NumViol[ColNum]++
+				compensatedAdd(SumViol, SumViolC, ColNum, Violation*Grad[ColNum]/GradLenSq)
+				SumWeightedViol[ColNum] = SumWeightedViol[ColNum] + Violation*Grad[ColNum]/GradLenSq*math.Abs(Violation)
+				SumWeights[ColNum] = SumWeights[ColNum] + math.Abs(Violation)
+			}
+		}
+
 		// Run through the bounds looking for violations and making appropriate updates
 		for ivar := 0; ivar < lp.NumCols; ivar++ {
-			if CCPoint[ivar] >= lp.LP.Cols[ivar].BndLo-Alpha {
-				// greater than lower bound
-				if CCPoint[ivar] <= lp.LP.Cols[ivar].BndUp+Alpha {
-					// less than upper bound
+			rhold = BoundViolation(ivar, CCPoint[ivar])
+			if rhold == 0.0 {
+				continue
+			}
+			NINF++
+			NumViol[ivar]++
+			compensatedAdd(SumViol, SumViolC, ivar, rhold)
+			SFD = SFD + math.Abs(rhold)
+			SumWeightedViol[ivar] = SumViol[ivar]
+			SumWeights[ivar] = SumWeights[ivar] + rhold
+		}
+
+		// In the MIP mode, integrality is a constraint whose feasibility vector points at the nearest integer
+		if Rounded != nil {
+			for ivar := 0; ivar < lp.NumCols; ivar++ {
+				rhold = IntViolation(ivar, CCPoint[ivar])
+				if rhold == 0.0 {
 					continue
-				} else if CCPoint[ivar]-lp.LP.Cols[ivar].BndUp > Alpha {
-					// upper bound violated by large enough amount
-					NINF++
-					NumViol[ivar]++
-					rhold = lp.LP.Cols[ivar].BndUp - CCPoint[ivar]
-					SumViol[ivar] = SumViol[ivar] + rhold
-					SFD = SFD + math.Abs(rhold)
-					SumWeightedViol[ivar] = SumViol[ivar]
-					SumWeights[ivar] = SumWeights[ivar] + rhold
 				}
-			} else if lp.LP.Cols[ivar].BndLo-CCPoint[ivar] > Alpha {
-				// lower bound violated by large enough amount
 				NINF++
 				NumViol[ivar]++
-				rhold = lp.LP.Cols[ivar].BndLo - CCPoint[ivar]
-				SumViol[ivar] = SumViol[ivar] + rhold
-				SFD = SFD + rhold
-				SumWeightedViol[ivar] = SumViol[ivar]
-				SumWeights[ivar] = SumWeights[ivar] + lp.LP.Cols[ivar].BndLo - CCPoint[ivar]
+				compensatedAdd(SumViol, SumViolC, ivar, rhold)
+				SFD = SFD + math.Abs(rhold)
+			}
+		}
+
+		for iset := range lp.LP.SOS {
+			SetViol, First, Last := SOSViolation(iset, CCPoint)
+			if SetViol == 0.0 {
+				continue
+			}
+			NINF++
+			SFD = SFD + SetViol
+			for k, ivar := range lp.LP.SOS[iset].Cols {
+				if k < First || k > Last {
+					NumViol[ivar]++
+					compensatedAdd(SumViol, SumViolC, ivar, -CCPoint[ivar])
+				}
 			}
 		}
 	
@@ -1372,6 +1500,9 @@ func CCSimple(PointIn []float64, chPointData chan POINTDATA, PointID int) {
 				CV[ivar] = 0.0
 				continue
 			}
+			if SumViolC != nil {
+				SumViol[ivar] = SumViol[ivar] + SumViolC[ivar]
+			}
 			CV[ivar] = SumViol[ivar] / float64(NumViol[ivar]) // For standard basic CC
 			rhold = rhold + CV[ivar]*CV[ivar]
 		}
@@ -1379,7 +1510,13 @@ func CCSimple(PointIn []float64, chPointData chan POINTDATA, PointID int) {
 		// Update the point and continue
 		for ivar := 0; ivar < lp.NumCols; ivar++ {
 			CCPoint[ivar] = CCPoint[ivar] + CV[ivar] //* MaxMultiplier
+			if math.IsNaN(CCPoint[ivar]) || math.IsInf(CCPoint[ivar], 0) {
+				// The CC step has diverged: discard this sample point
+				PointOut.Status = 1
+				chPointData <- PointOut
+				return
 			}
+		}
 		_ = UpdateIncumbentSFD(CCPoint, SFD, NINF, PointID)	
 	} // end of CC iteration loop
 	
@@ -1393,8 +1530,54 @@ func CCSimple(PointIn []float64, chPointData chan POINTDATA, PointID int) {
 
 //========================================================================================
 // The overall solution control routine. Must be called first to give global variables their values
-// Status values: 0:(success), 1:(max iterations reached or failure), 2:(numerical problem)
+// Status values: 0:(success), 1:(max iterations reached or failure), 2:(numerical problem), 3:(time limit reached)
 func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, plinfyIn float64, featolIn float64) (PointOut []float64, Status int) {
+	return solveFrom(WARMSTART{}, AlphaIn, BetaIn, MaxItnsIn, MaxSwarmPtsIn, plinfyIn, featolIn)
+}
+
+//========================================================================================
+// Solve, warm-started from Warm, e.g. the LastWarmStart of a solve of the model before it was
+// changed. The status values are those of Solve.
+func SolveWarm(Warm WARMSTART, AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, plinfyIn float64, featolIn float64) (PointOut []float64, Status int) {
+	return solveFrom(Warm, AlphaIn, BetaIn, MaxItnsIn, MaxSwarmPtsIn, plinfyIn, featolIn)
+}
+
+//========================================================================================
+// The incumbent and the last sample box of the last call to Solve, as a warm start for the
+// next one. Points is nil if there has been no solve.
+func LastWarmStart() (Warm WARMSTART) {
+	if IncumbentPt == nil || IncumbentNINF == math.MaxInt32 {
+		return Warm
+	}
+	Warm.Points = [][]float64{append([]float64(nil), IncumbentPt...)}
+	Warm.BoxLo = append([]float64(nil), BoxBndLo...)
+	Warm.BoxUp = append([]float64(nil), BoxBndUp...)
+	return Warm
+}
+
+//========================================================================================
+// Solve from a warm start if Warm.Points or Warm.BoxLo is set. The start points are the
+// first incumbents, and Solve returns at once if one of them is feasible; otherwise the first
+// CC runs of the first round start at them, one point per run. The first sample boxes are Warm.BoxLo and
+// Warm.BoxUp cut down to the column bounds, or centred on the best start point (the SFD
+// incumbent) if no box is given or a column's box lies outside its bounds, which may have
+// changed since the box was saved. A start point or box that doesn't fit the model is dropped
+// with a warning.
+func solveFrom(Warm WARMSTART, AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, plinfyIn float64, featolIn float64) (PointOut []float64, Status int) {
+
+	var Starts [][]float64
+	for k, Point := range Warm.Points {
+		if len(Point) != lp.NumCols {
+			fmt.Println("Warning: warm start point", k, "has", len(Point), "values but the model has", lp.NumCols, "columns. Not used.")
+			continue
+		}
+		Starts = append(Starts, Point)
+	}
+	WarmLo, WarmUp := Warm.BoxLo, Warm.BoxUp
+	if WarmLo != nil && (len(WarmLo) != lp.NumCols || len(WarmUp) != lp.NumCols) {
+		fmt.Println("Warning: the warm start box does not have one bound per column. Not used.")
+		WarmLo, WarmUp = nil, nil
+	}
 
 	// Set up the swarm of points and related info
 	MaxSwarmPts = MaxSwarmPtsIn
@@ -1421,6 +1604,9 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 	IncumbentSFD = math.MaxFloat64  // Initial huge value
 	//IncumbentNINF = -1              // Initial impossible value
 	IncumbentNINF = math.MaxInt32
+	NIncumbentPt = make([]float64, lp.NumCols)
+	NIncumbentSFD = math.MaxFloat64
+	NIncumbentNINF = math.MaxInt32
 
 	// To keep statistics on updates to the incumbent
 	NumUpdate = make([]int, 23)
@@ -1444,6 +1630,7 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 	QuadProjFails = 0
 	QuadProjFrac = 0.0
 	FailedRounds = 0
+	NumNumericalFails = 0
 
 	// Local variables
 	//CCEndPt := make([]float64, lp.NumCols)
@@ -1455,10 +1642,15 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 	
 	var AvgSFD, LastAvgSFD float64
 	var icount int
+	var NumGood int // Number of CC runs in the round that did not hit a numerical problem
 	
-	var NumCCRuns int = 0 // counts the number of CC runs that complete (some runs killed if soln found)
+	NumCCRuns = 0 // counts the number of CC runs that complete (some runs killed if soln found)
+	StartTime := time.Now()
 	//	var SomeIdentical bool
-	var MaxBoxes int = 100 // The maximum number of sample boxes. TODO: pass in as proper control parameter
+	MaxBoxes := MaxRounds // The maximum number of sample boxes
+	if MaxBoxes <= 0 {
+		MaxBoxes = 100
+	}
 
 	//test
 	//fmt.Println("In solve: CC end pt after initial definition:",CCEndPt)
@@ -1479,23 +1671,67 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 	//}
 	
 	// Set up the random number generator
-	RandNum := rand.New(rand.NewSource(time.Now().UnixNano()))
+	SeedUsed = RandomSeed
+	if SeedUsed == 0 {SeedUsed = time.Now().UnixNano()}
+	RandNum := rand.New(rand.NewSource(SeedUsed))
 	
+	// The start points are the first incumbents
+	var Start []float64 // The best of them
+	var StartFeasible bool
+	for _, Point := range Starts {
+		SFDStatus, SFD, _, _, _, StartNINF := GetSFD(Point)
+		if SFDStatus == 2 {
+			continue
+		}
+		_ = UpdateIncumbentSFD(Point, SFD, StartNINF, 0)
+		if SFDStatus == 1 {
+			StartFeasible = true
+			break
+		}
+	}
+	if IncumbentNINF < math.MaxInt32 {
+		Start = IncumbentPt
+		if PrintLevel > 0 && !StartFeasible {fmt.Println("Best of", len(Starts), "warm start points: SFD", IncumbentSFD, "NINF", IncumbentNINF)}
+	}
+
 	// Initialize the sample box bounds
 	MaxWidth = 0.0; AvgWidth = 0.0
 	for j:=0; j<lp.NumCols; j++ {
-		BoxBndLo[j] = lp.LP.Cols[j].BndLo
+		// The convex hull of the column's domain, which takes in 0 for a semi-continuous column
+		HullLo, HullUp := HullBounds(j)
+		BoxBndLo[j] = HullLo
 		BoxBndUp[j] = BoxBndLo[j] + 10000.0
-		if BoxBndUp[j] > lp.LP.Cols[j].BndUp {BoxBndUp[j] = lp.LP.Cols[j].BndUp}
+		if BoxBndUp[j] > HullUp {BoxBndUp[j] = HullUp}
+		if Start != nil {
+			// Centred on the start point, within the bounds
+			rhold = WarmBoxFrac*math.Max(1.0, math.Abs(Start[j]))
+			BoxBndLo[j] = math.Max(Start[j]-rhold, HullLo)
+			BoxBndUp[j] = math.Min(Start[j]+rhold, HullUp)
+		}
+		if WarmLo != nil && math.Max(WarmLo[j], HullLo) <= math.Min(WarmUp[j], HullUp) {
+			BoxBndLo[j] = math.Max(WarmLo[j], HullLo)
+			BoxBndUp[j] = math.Min(WarmUp[j], HullUp)
+		}
 		rhold = BoxBndUp[j] - BoxBndLo[j]
 		AvgWidth = AvgWidth + rhold
 		if rhold > MaxWidth {MaxWidth = rhold}
 	}
 	AvgWidth = AvgWidth/float64(lp.NumCols)
 	LastAvgSFD = plinfy
+	if StartFeasible {
+		// Returned after the sample boxes are set, which LastWarmStart passes on to the next solve
+		if PrintLevel > 0 {fmt.Println("\nA warm start point is feasible.")}
+		FinalBox = 0
+		return IncumbentPt, 0
+	}
 
 	// Large iteration loop on rounds (boxes) starts here
 	for itn := 0; itn < MaxBoxes; itn++ {
+		if TimeLimit > 0 && time.Since(StartTime) > TimeLimit {
+			if PrintLevel > 0 {fmt.Println("Time limit reached after", itn, "rounds.")}
+			return IncumbentPt, 3
+		}
+		FinalBox = itn
 		// Zero out the statistics accumulators
 		for j:=0; j<lp.NumCols; j++ {
 			M[j] = 0.0
@@ -1508,29 +1744,52 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 			fmt.Println("Average sample box width:",AvgWidth,"Max width:",MaxWidth)
 		}
 		
-		// Launch the CC runs
+		// Launch the CC runs. Each gets its own start point since the runs start asynchronously.
 		for i := 0; i < 100; i++ {
 			// Generate a random point
+			StartPt := make([]float64, lp.NumCols)
 			for j:=0; j<lp.NumCols; j++ {
-				SamplePt.Point[j] = BoxBndLo[j] + RandNum.Float64()*(BoxBndUp[j] - BoxBndLo[j])
+				StartPt[j] = BoxBndLo[j] + RandNum.Float64()*(BoxBndUp[j] - BoxBndLo[j])
+				if lp.NumSCols > 0 {
+					StartPt[j] = SnapSemi(j, StartPt[j]) // no start point in the gap of a semi-continuous domain
+				}
+			}
+			if itn == 0 && i < len(Starts) {
+				copy(StartPt, Starts[i])
 			}
-			go CCSimple(SamplePt.Point, chPointData, i)
+			go CCSimple(StartPt, chPointData, i)
 		}
 
 		// Retrieve the CC output points
 		AvgSFD = 0.0
 		icount = 0
+		NumGood = 0
 		for i := 0; i < 100; i++ {
 			SamplePt = <-chPointData
-			AvgSFD = AvgSFD + SamplePt.SFD
 			NumCCRuns++ // increment the counter on the number of CC runs
+			if SamplePt.Status > 0 {
+				// Numerical problem in this CC run, so ignore the point
+				NumNumericalFails++
+				continue
+			}
+			NumGood++
+			AvgSFD = AvgSFD + SamplePt.SFD
 			if SamplePt.NINF == 0 {
 				if PrintLevel > 0 {
-					fmt.Println("\nFEASIBLE SOLUTION FOUND after", NumCCRuns, "CC runs processed.\n")
+					fmt.Println("\nFEASIBLE SOLUTION FOUND after", NumCCRuns, "CC runs processed.")
+					fmt.Println()
 				}
 				copy(IncumbentPt, SamplePt.Point)
 				IncumbentSFD = 0.0
 				IncumbentNINF = 0
+				copy(NIncumbentPt, SamplePt.Point)
+				NIncumbentSFD = 0.0
+				NIncumbentNINF = 0
+				// Collect the remaining CC runs so none is left running against the model,
+				// which may be emptied and replaced as soon as we return.
+				for i1 := i + 1; i1 < 100; i1++ {
+					<-chPointData
+				}
 				return IncumbentPt, 0
 			}
 			// Update the mean and variance accumulators
@@ -1555,7 +1814,36 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 //				if SamplePt[j] > Q[j] {Q[j] = SamplePt[j]}
 //			}
 		}
-		LastAvgSFD = AvgSFD/100.0
+		if LocalSearch && lp.NumICols > 0 && lp.NumQRows == 0 && len(Constraints) == 0 && NIncumbentNINF < math.MaxInt32 {
+			// Improvement heuristic: local search on the integer columns from the NINF incumbent
+			LSResult, LSStatus := LocalSearchPoint(NIncumbentPt, RandNum)
+			if PrintLevel > 0 {
+				fmt.Println("Local search: NINF", LSResult.StartNINF, "after rounding,", LSResult.NINF, "after", LSResult.NumMoves, "moves")
+			}
+			if LSStatus == 0 {
+				if GetSFDStatus, _, _, _, _, _ := GetSFD(LSResult.Point); GetSFDStatus == 1 {
+					if PrintLevel > 0 {
+						fmt.Println("\nFEASIBLE SOLUTION FOUND by the local search after", NumCCRuns, "CC runs processed.")
+					}
+					copy(IncumbentPt, LSResult.Point)
+					IncumbentSFD = 0.0
+					IncumbentNINF = 0
+					copy(NIncumbentPt, LSResult.Point)
+					NIncumbentSFD = 0.0
+					NIncumbentNINF = 0
+					return IncumbentPt, 0
+				}
+			}
+			if LSStatus < 2 {
+				_ = UpdateIncumbentSFD(LSResult.Point, LSResult.SFD, LSResult.NINF, 0)
+			}
+		}
+		if NumGood == 0 || icount == 0 {
+			// Every point in the round was discarded or worse than average: keep the same boxes
+			if PrintLevel > 0 {fmt.Println("No usable points in round",itn,". Sample boxes unchanged.")}
+			continue
+		}
+		LastAvgSFD = AvgSFD/float64(NumGood)
 		
 		//Set up the new sample boxes based on the mean and standard deviation
 		MaxWidth = 0.0; AvgWidth = 0.0
@@ -1566,11 +1854,12 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 //			if BoxBndLo[j] > lp.LP.Cols[j].BndUp {BoxBndLo[j] = lp.LP.Cols[j].BndUp}
 //			BoxBndUp[j] = Q[j]
 //			if BoxBndUp[j] < lp.LP.Cols[j].BndLo {BoxBndUp[j] = lp.LP.Cols[j].BndLo}
+			HullLo, HullUp := HullBounds(j)
 			BoxBndLo[j] = M[j] - 1.5*rhold
-			if BoxBndLo[j] > lp.LP.Cols[j].BndUp {BoxBndLo[j] = lp.LP.Cols[j].BndUp}			
+			if BoxBndLo[j] > HullUp {BoxBndLo[j] = HullUp}			
 //			if BoxBndLo[j] < lp.LP.Cols[j].BndLo {BoxBndLo[j] = lp.LP.Cols[j].BndLo}
 			BoxBndUp[j] = M[j] + 1.5*rhold
-			if BoxBndUp[j] < lp.LP.Cols[j].BndLo {BoxBndUp[j] = lp.LP.Cols[j].BndLo}
+			if BoxBndUp[j] < HullLo {BoxBndUp[j] = HullLo}
 //			if BoxBndUp[j] > lp.LP.Cols[j].BndUp {BoxBndUp[j] = lp.LP.Cols[j].BndUp}
 			if BoxBndUp[j] < BoxBndLo[j] {
 				fmt.Println("Reversed bounds for variable",j,"corrected.")
@@ -1587,8 +1876,28 @@ func Solve(AlphaIn float64, BetaIn float64, MaxItnsIn int, MaxSwarmPtsIn int, pl
 	return IncumbentPt, 1
 }
 
+//=======================================================================================
+// Adds x to Sum[i]. If Comp is not nil (the lp.AccurateSums mode), the rounding error of the
+// addition is accumulated in Comp[i] (Neumaier's variant of Kahan summation); the caller adds
+// Comp[i] to Sum[i] once the accumulation is over.
+func compensatedAdd(Sum []float64, Comp []float64, i int, x float64) {
+	if Comp == nil {
+		Sum[i] = Sum[i] + x
+		return
+	}
+	t := Sum[i] + x
+	if math.Abs(Sum[i]) >= math.Abs(x) {
+		Comp[i] = Comp[i] + ((Sum[i] - t) + x)
+	} else {
+		Comp[i] = Comp[i] + ((x - t) + Sum[i])
+	}
+	Sum[i] = t
+}
+
 //======================================================================================
-// Tests a point in the way that a typical solver would do it: by comparing LHS and RHS
+// Tests a point in the way that a typical solver would do it: by comparing LHS and RHS,
+// with the tolerances of the feasibility oracle (TolMode). In the MIP mode a fractional integer
+// column counts as a violation; GetFractionality gives the details.
 // Status: 0:(success), 1:(trouble evaluating one or more functions)
 func TestPoint(PointIn []float64) (Status, NINF, NumSat, NumTight int, SINF, MaxViol, AvgViol float64) {
 
@@ -1642,14 +1951,53 @@ func TestPoint(PointIn []float64) (Status, NINF, NumSat, NumTight int, SINF, Max
 		}
 	}
 
+	// Custom constraints
+	for ic := range Constraints {
+		CStatus, CViol, _ := ConstraintViolation(ic, PointIn, TolMode)
+		if CStatus > 0 {
+			fmt.Println("Error evaluating constraint", Constraints[ic].Name(), ". Skipping it.")
+			Status = 1
+			continue
+		}
+		if CViol == 0.0 {
+			NumSat++
+			continue
+		}
+		NINF++
+		SINF = SINF + math.Abs(CViol)
+		if math.Abs(CViol) > MaxViol {
+			MaxViol = math.Abs(CViol)
+		}
+	}
+
 	// Run through the bounds testing for violations, tightness, etc.
 	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		if lp.LP.Cols[ivar].Type.IsSemi() {
+			// 0 or between the bounds: BoundViolation measures to the nearer part of the domain
+			if Violation = math.Abs(BoundViolation(ivar, PointIn[ivar])); Violation > 0.0 {
+				SINF = SINF + Violation
+				NINF++
+				if Violation > MaxViol {
+					MaxViol = Violation
+				}
+				continue
+			}
+			if math.Abs(PointIn[ivar]) <= BoundTolerance(0.0, TolMode) && lp.LP.Cols[ivar].BndLo > 0.0 {
+				// Switched off: the bounds do not apply
+				NumSat++
+				if lp.LP.Cols[ivar].BndUp < plinfy {
+					NumSat++
+				}
+				continue
+			}
+		}
 		if lp.LP.Cols[ivar].BndLo > -plinfy {
 			// There is a lower bound
-			if PointIn[ivar] >= lp.LP.Cols[ivar].BndLo-featol {
+			Tol := BoundTolerance(lp.LP.Cols[ivar].BndLo, TolMode)
+			if PointIn[ivar] >= lp.LP.Cols[ivar].BndLo-Tol {
 				// Lower bound is satisfied, and perhaps tight
 				NumSat++
-				if PointIn[ivar] <= lp.LP.Cols[ivar].BndLo+featol {
+				if PointIn[ivar] <= lp.LP.Cols[ivar].BndLo+Tol {
 					NumTight++
 				}
 			} else {
@@ -1664,10 +2012,11 @@ func TestPoint(PointIn []float64) (Status, NINF, NumSat, NumTight int, SINF, Max
 		}
 		if lp.LP.Cols[ivar].BndUp < plinfy {
 			// There is an upper bound
-			if PointIn[ivar] <= lp.LP.Cols[ivar].BndUp+featol {
+			Tol := BoundTolerance(lp.LP.Cols[ivar].BndUp, TolMode)
+			if PointIn[ivar] <= lp.LP.Cols[ivar].BndUp+Tol {
 				// Upper bound is satisified and perhaps tight
 				NumSat++
-				if PointIn[ivar] >= lp.LP.Cols[ivar].BndUp-featol {
+				if PointIn[ivar] >= lp.LP.Cols[ivar].BndUp-Tol {
 					NumTight++
 				}
 			} else {
@@ -1680,6 +2029,27 @@ func TestPoint(PointIn []float64) (Status, NINF, NumSat, NumTight int, SINF, Max
 				}
 			}
 		}
+		// In the MIP mode integrality is one more constraint
+		if Violation = math.Abs(IntViolation(ivar, PointIn[ivar])); Violation > 0.0 {
+			SINF = SINF + Violation
+			NINF++
+			if Violation > MaxViol {
+				MaxViol = Violation
+			}
+		}
+	}
+
+	// Special ordered sets
+	for iset := range lp.LP.SOS {
+		if Violation, _, _ = SOSViolation(iset, PointIn); Violation == 0.0 {
+			NumSat++
+			continue
+		}
+		SINF = SINF + Violation
+		NINF++
+		if Violation > MaxViol {
+			MaxViol = Violation
+		}
 	}
 
 	AvgViol = 0.0
@@ -1790,10 +2160,6 @@ func NewPoints1(Round int) {
 				rhold1 = Violation * lp.Element[ElNum].Value / lp.LP.Rows[icon].GradVecLenSq
 				rhold = rhold + rhold1*rhold1
 			}
-			if rhold < Alpha*Alpha {
-				// Feasibility vector is too short so skip this constraint
-				continue
-			}
 			FVLength = math.Sqrt(rhold)
 			SFD = SFD + FVLength
 
@@ -1851,50 +2217,28 @@ func NewPoints1(Round int) {
 
 		// Run through the bounds looking for violations and making appropriate updates
 		for ivar := 0; ivar < lp.NumCols; ivar++ {
-			if IncumbentPt[ivar] >= lp.LP.Cols[ivar].BndLo-featol {
-				// greater than lower bound
-				if IncumbentPt[ivar] <= lp.LP.Cols[ivar].BndUp+featol {
-					// less than upper bound
-					continue
-				} else if IncumbentPt[ivar]-lp.LP.Cols[ivar].BndUp > Alpha {
-					// upper bound violated by large enough amount
-					NINF++
-					VotesDown[ivar]++
-					NumViol[ivar]++
-					rhold = lp.LP.Cols[ivar].BndUp - IncumbentPt[ivar]
-					SumViol[ivar] = SumViol[ivar] + rhold
-					SFD = SFD + math.Abs(rhold)
-
-					if IncumbentPt[ivar]-lp.LP.Cols[ivar].BndUp > MaxFVLength {
-						// There's a new maximum violation
-						MaxFVLength = IncumbentPt[ivar] - lp.LP.Cols[ivar].BndUp
-						// Empty the old FVMaxViol vector, fill it in the following step
-						for j := 0; j < lp.LP.NumCols; j++ {
-							FVMaxFVLength[j] = 0.0
-						}
-						FVMaxFVLength[ivar] = lp.LP.Cols[ivar].BndUp - IncumbentPt[ivar]
-					}
-
-				}
-			} else if lp.LP.Cols[ivar].BndLo-IncumbentPt[ivar] > Alpha {
-				// lower bound violated by large enough amount
-				NINF++
+			rhold = BoundViolation(ivar, IncumbentPt[ivar])
+			if rhold == 0.0 {
+				continue
+			}
+			NINF++
+			if rhold > 0.0 {
 				VotesUp[ivar]++
-				NumViol[ivar]++
-				rhold = lp.LP.Cols[ivar].BndLo - IncumbentPt[ivar]
-				SumViol[ivar] = SumViol[ivar] + rhold
-				SFD = SFD + rhold
+			} else {
+				VotesDown[ivar]++
+			}
+			NumViol[ivar]++
+			SumViol[ivar] = SumViol[ivar] + rhold
+			SFD = SFD + math.Abs(rhold)
 
-				if lp.LP.Cols[ivar].BndLo-IncumbentPt[ivar] > MaxFVLength {
-					// There's a new maximum violation
-					MaxFVLength = lp.LP.Cols[ivar].BndLo - IncumbentPt[ivar]
-					// Empty the old FVMaxViol vector, fill it in the following step
-					for j := 0; j < lp.LP.NumCols; j++ {
-						FVMaxFVLength[j] = 0.0
-					}
-					FVMaxFVLength[ivar] = lp.LP.Cols[ivar].BndLo - IncumbentPt[ivar]
+			if math.Abs(rhold) > MaxFVLength {
+				// There's a new maximum violation
+				MaxFVLength = math.Abs(rhold)
+				// Empty the old FVMaxViol vector, fill it in the following step
+				for j := 0; j < lp.LP.NumCols; j++ {
+					FVMaxFVLength[j] = 0.0
 				}
-
+				FVMaxFVLength[ivar] = rhold
 			}
 		} // end of loop on ivar
 
@@ -2143,10 +2487,6 @@ func NewPoints2(Round int) {
 				rhold1 = Violation * lp.Element[ElNum].Value / lp.LP.Rows[icon].GradVecLenSq
 				rhold = rhold + rhold1*rhold1
 			}
-			if rhold < Alpha*Alpha {
-				// Feasibility vector is too short so skip this constraint
-				continue
-			}
 			FVLength = math.Sqrt(rhold)
 			SFD = SFD + FVLength
 
@@ -2204,50 +2544,28 @@ func NewPoints2(Round int) {
 
 		// Run through the bounds looking for violations and making appropriate updates
 		for ivar := 0; ivar < lp.NumCols; ivar++ {
-			if IncumbentPt[ivar] >= lp.LP.Cols[ivar].BndLo-featol {
-				// greater than lower bound
-				if IncumbentPt[ivar] <= lp.LP.Cols[ivar].BndUp+featol {
-					// less than upper bound
-					continue
-				} else if IncumbentPt[ivar]-lp.LP.Cols[ivar].BndUp > Alpha {
-					// upper bound violated by large enough amount
-					NINF++
-					VotesDown[ivar]++
-					NumViol[ivar]++
-					rhold = lp.LP.Cols[ivar].BndUp - IncumbentPt[ivar]
-					SumViol[ivar] = SumViol[ivar] + rhold
-					SFD = SFD + math.Abs(rhold)
-
-					if IncumbentPt[ivar]-lp.LP.Cols[ivar].BndUp > MaxFVLength {
-						// There's a new maximum violation
-						MaxFVLength = IncumbentPt[ivar] - lp.LP.Cols[ivar].BndUp
-						// Empty the old FVMaxViol vector, fill it in the following step
-						for j := 0; j < lp.LP.NumCols; j++ {
-							FVMaxFVLength[j] = 0.0
-						}
-						FVMaxFVLength[ivar] = lp.LP.Cols[ivar].BndUp - IncumbentPt[ivar]
-					}
-
-				}
-			} else if lp.LP.Cols[ivar].BndLo-IncumbentPt[ivar] > Alpha {
-				// lower bound violated by large enough amount
-				NINF++
+			rhold = BoundViolation(ivar, IncumbentPt[ivar])
+			if rhold == 0.0 {
+				continue
+			}
+			NINF++
+			if rhold > 0.0 {
 				VotesUp[ivar]++
-				NumViol[ivar]++
-				rhold = lp.LP.Cols[ivar].BndLo - IncumbentPt[ivar]
-				SumViol[ivar] = SumViol[ivar] + rhold
-				SFD = SFD + rhold
+			} else {
+				VotesDown[ivar]++
+			}
+			NumViol[ivar]++
+			SumViol[ivar] = SumViol[ivar] + rhold
+			SFD = SFD + math.Abs(rhold)
 
-				if lp.LP.Cols[ivar].BndLo-IncumbentPt[ivar] > MaxFVLength {
-					// There's a new maximum violation
-					MaxFVLength = lp.LP.Cols[ivar].BndLo - IncumbentPt[ivar]
-					// Empty the old FVMaxViol vector, fill it in the following step
-					for j := 0; j < lp.LP.NumCols; j++ {
-						FVMaxFVLength[j] = 0.0
-					}
-					FVMaxFVLength[ivar] = lp.LP.Cols[ivar].BndLo - IncumbentPt[ivar]
+			if math.Abs(rhold) > MaxFVLength {
+				// There's a new maximum violation
+				MaxFVLength = math.Abs(rhold)
+				// Empty the old FVMaxViol vector, fill it in the following step
+				for j := 0; j < lp.LP.NumCols; j++ {
+					FVMaxFVLength[j] = 0.0
 				}
-
+				FVMaxFVLength[ivar] = rhold
 			}
 		} // end of loop on ivar
 
@@ -2488,7 +2806,9 @@ func SwarmSearch4() (Status int) {
 				return 1
 			}
 			if Status > 1 {
-				fmt.Println("***Status > 1 for GetSFD call in SwarmSearch.")
+				// NaN or infinite values: discard the point
+				NumNumericalFails++
+				continue
 			}
 			if SFD < SwarmSFD[ipt] {
 				copy(Swarm[ipt], TryPoint)
@@ -2519,7 +2839,9 @@ func SwarmSearch4() (Status int) {
 				return 1
 			}
 			if Status > 1 {
-				fmt.Println("***Status > 1 for TestPoint call in SwarmSearch.")
+				// NaN or infinite values: discard the point
+				NumNumericalFails++
+				continue
 			}
 			// If the reflected point is better than the swarmpoint, update it
 			if SFD < SwarmSFD[ipt] {
@@ -2561,7 +2883,9 @@ func SwarmSearch4() (Status int) {
 				return 1
 			}
 			if Status > 1 {
-				fmt.Println("***Status > 1 for GetSFD call in SwarmSearch.")
+				// NaN or infinite values: discard the point
+				NumNumericalFails++
+				continue
 			}
 			if SFD < SwarmSFD[ipt] {
 				copy(Swarm[ipt], TryPoint)
@@ -2664,7 +2988,9 @@ func SwarmSearch5() (Status int) {
 				return 1
 			}
 			if Status > 1 {
-				fmt.Println("***Status > 1 for GetSFD call in SwarmSearch.")
+				// NaN or infinite values: discard the point
+				NumNumericalFails++
+				continue
 			}
 			if SFD < SwarmSFD[ipt] {
 				copy(Swarm[ipt], TryPoint)
@@ -2695,7 +3021,9 @@ func SwarmSearch5() (Status int) {
 				return 1
 			}
 			if Status > 1 {
-				fmt.Println("***Status > 1 for TestPoint call in SwarmSearch.")
+				// NaN or infinite values: discard the point
+				NumNumericalFails++
+				continue
 			}
 			// If the reflected point is better than the swarmpoint, update it
 			if SFD < SwarmSFD[ipt] {
@@ -2737,7 +3065,9 @@ func SwarmSearch5() (Status int) {
 				return 1
 			}
 			if Status > 1 {
-				fmt.Println("***Status > 1 for GetSFD call in SwarmSearch.")
+				// NaN or infinite values: discard the point
+				NumNumericalFails++
+				continue
 			}
 			if SFD < SwarmSFD[ipt] {
 				copy(Swarm[ipt], TryPoint)
@@ -2964,9 +3294,22 @@ func UpdateIncumbentSFDforNINF(PointIn []float64, SFDin float64, NINFin int, Upd
 
 //=======================================================================================================
 // Update the incumbent point based on sum of feasibility distances.
+// Every CCSimple run calls this at the end of each CC iteration, and Solve starts the runs as
+// goroutines, so the updates are locked: two runs copying into IncumbentPt or NIncumbentPt at
+// once would leave a point made of parts of both.
 // Status: 0(updated value), 1(feasible point), 2(input point not an improvement)
 func UpdateIncumbentSFD(PointIn []float64, SFDin float64, NINFin int, UpdatedBy int) (Status int) {
 
+	incumbentLock.Lock()
+	defer incumbentLock.Unlock()
+
+	// The NINF incumbent: fewest violations, ties broken by SFD. Used by the IIS analysis.
+	if NIncumbentPt != nil && (NINFin < NIncumbentNINF || (NINFin == NIncumbentNINF && SFDin < NIncumbentSFD)) {
+		copy(NIncumbentPt, PointIn)
+		NIncumbentSFD = SFDin
+		NIncumbentNINF = NINFin
+	}
+
 	// Return immediately if SFD has not improved.
 	if SFDin > IncumbentSFD {
 		return 2
@@ -3735,21 +4078,26 @@ func SwarmProject1(Pt0 []float64, UpdateVector []float64) (Status int, PointOut
 //===========================================================================================================
 // For a given input point, this routine returns the sum of the feasibility distances SFDout, the largest
 // feasibility distance MaxFDout, and the number of the constraint or variable that MaxFDout is
-// associated with.
+// associated with. In the MIP mode the distance of a fractional integer column to the nearest
+// integer is a feasibility distance too, reported through MaxFDVar. So is a violated special
+// ordered set, through its largest member that should be 0. The distance of a quadratic row or
+// custom constraint is first order: the violation over the length of the gradient at the
+// point. A custom constraint with the largest distance leaves MaxFDCon and MaxFDVar at -1.
+// On a numerical problem SFDout is +Inf and NINF is -1, so the point can't pass for a feasible one.
 // Status: 0(successful), 1(successful and feasible), 2(numerical problem)
 func GetSFD(PointIn []float64) (Status int, SFDout float64, MaxFDout float64, MaxFDCon int, MaxFDVar int, NINF int) {
 
 	// Local variables
 	var FVStatus, ViolStatus int
 	var Violation float64
-	var ElNum int
-	var rhold, rhold1 float64
+	var rhold float64
+	var Grad []float64 // Gradient of a quadratic row at the point
 
 	//test
 	if math.IsNaN(PointIn[0]) {
 		fmt.Println("***1 GetViolation called with NaN PointIn in GetSFD. Exiting GetSFD.")
 		//os.Exit(1)}
-		return 2, 0.0, 0.0, -1, -1, 0
+		return 2, math.Inf(1), 0.0, -1, -1, -1
 	}
 
 	Status = 0
@@ -3768,28 +4116,27 @@ func GetSFD(PointIn []float64) (Status int, SFDout float64, MaxFDout float64, Ma
 		//fmt.Println("After GetViolation call for con",icon,"Violation:",Violation,"FVStatus:",FVStatus,"ViolStatus:",ViolStatus)
 
 		if FVStatus > 0 {
-			fmt.Println("Error evaluating feasibility status for constraint", icon, "in GetSFD. Skipping it.")
-			continue
+			// Numerical problem, so the point cannot be assessed
+			return 2, math.Inf(1), 0.0, -1, -1, -1
 		}
 		if ViolStatus > 0 {
 			// not violated, so skip
 			continue
 		}
 
-		// Check length of feasibility vector
-		rhold = 0.0 // Accumulates length of feasibility vector
-		for iel := 0; iel < lp.LP.Rows[icon].NumEl; iel++ {
-			ElNum = lp.LP.Rows[icon].ElList[iel]
-			rhold1 = Violation * lp.Element[ElNum].Value / lp.LP.Rows[icon].GradVecLenSq
-			rhold = rhold + rhold1*rhold1
-		}
-		if rhold < Alpha*Alpha {
-			// Feasibility vector is too short
-			continue
+		rhold = math.Abs(Violation) * lp.LP.Rows[icon].InvGradVecLen // Length of feasibility vector
+		if len(lp.LP.Rows[icon].QTerms) > 0 {
+			// A quadratic row: the first-order distance, from the gradient at the point
+			if Grad == nil {
+				Grad = make([]float64, lp.NumCols)
+			}
+			rhold = math.Abs(Violation)
+			if GradLenSq := lp.QuadGradient(icon, PointIn, Grad); GradLenSq > 0.0 {
+				rhold = rhold / math.Sqrt(GradLenSq)
+			}
 		}
 
 		// Constraint is violated
-		rhold = math.Sqrt(rhold) // Converts to actual length from length squared
 		SFDout = SFDout + rhold
 		if rhold > MaxFDout {
 			MaxFDout = rhold
@@ -3798,35 +4145,74 @@ func GetSFD(PointIn []float64) (Status int, SFDout float64, MaxFDout float64, Ma
 		NINF++
 	}
 
+	// Custom constraints, with the first-order distance from the gradient at the point
+	for ic := range Constraints {
+		CStatus, CViol, _ := ConstraintViolation(ic, PointIn, TolMode)
+		if CStatus > 0 {
+			return 2, math.Inf(1), 0.0, -1, -1, -1
+		}
+		if CViol == 0.0 {
+			continue
+		}
+		if Grad == nil {
+			Grad = make([]float64, lp.NumCols)
+		}
+		rhold = math.Abs(CViol)
+		if GradLenSq := constraintGradient(ic, PointIn, Grad); GradLenSq > 0.0 {
+			rhold = rhold / math.Sqrt(GradLenSq)
+		}
+		NINF++
+		SFDout = SFDout + rhold
+		if rhold > MaxFDout {
+			// Not a row or a bound: MaxFDCon and MaxFDVar are both -1
+			MaxFDout = rhold
+			MaxFDCon = -1
+			MaxFDVar = -1
+		}
+	}
+
 	// Run through the bounds looking for violations and making appropriate updates
 	for ivar := 0; ivar < lp.NumCols; ivar++ {
-		if PointIn[ivar] >= lp.LP.Cols[ivar].BndLo-Alpha {
-			// greater than lower bound
-			if PointIn[ivar] <= lp.LP.Cols[ivar].BndUp+Alpha {
-				// less than upper bound
-				continue
-			} else if PointIn[ivar]-lp.LP.Cols[ivar].BndUp > Alpha {
-				// upper bound violated by large enough amount
-				NINF++
-				rhold = PointIn[ivar] - lp.LP.Cols[ivar].BndUp
-				SFDout = SFDout + rhold
-				if rhold > MaxFDout {
-					MaxFDout = rhold
-					MaxFDCon = -1
-					MaxFDVar = ivar
-				}
+		rhold = math.Abs(BoundViolation(ivar, PointIn[ivar]))
+		if rhold == 0.0 {
+			continue
+		}
+		NINF++
+		SFDout = SFDout + rhold
+		if rhold > MaxFDout {
+			MaxFDout = rhold
+			MaxFDCon = -1
+			MaxFDVar = ivar
+		}
+	}
 
-			}
-		} else if lp.LP.Cols[ivar].BndLo-PointIn[ivar] > Alpha {
-			// lower bound violated by large enough amount
-			NINF++
-			rhold = lp.LP.Cols[ivar].BndLo - PointIn[ivar]
-			SFDout = SFDout + rhold
-			if rhold > MaxFDout {
-				MaxFDout = rhold
-				MaxFDCon = -1
-				MaxFDVar = ivar
-			}
+	// In the MIP mode, the distances of the integer columns to the nearest integers
+	for ivar := 0; ivar < lp.NumCols; ivar++ {
+		rhold = math.Abs(IntViolation(ivar, PointIn[ivar]))
+		if rhold == 0.0 {
+			continue
+		}
+		NINF++
+		SFDout = SFDout + rhold
+		if rhold > MaxFDout {
+			MaxFDout = rhold
+			MaxFDCon = -1
+			MaxFDVar = ivar
+		}
+	}
+
+	// Special ordered sets: the worst member that should be zero stands for the set
+	for iset := range lp.LP.SOS {
+		rhold, _, _ = SOSViolation(iset, PointIn)
+		if rhold == 0.0 {
+			continue
+		}
+		NINF++
+		SFDout = SFDout + rhold
+		if rhold > MaxFDout {
+			MaxFDout = rhold
+			MaxFDCon = -1
+			MaxFDVar = sosWorstMember(iset, PointIn)
 		}
 	}
 
@@ -3847,11 +4233,10 @@ func GetMultiplier(X0, X1 []float64, CBIndex int, ConOrBnd int) (Status int, Mul
 
 	var Violation0, Violation1, Diff float64
 	var FVStatus, ViolStatus int
-	var Lower, Upper float64
 
 	if ConOrBnd == 0 {
 		// It's a row constraint
-		if lp.LP.Rows[CBIndex].Type == "N" {
+		if lp.LP.Rows[CBIndex].Type == lp.RowN {
 			return 1, 0.0
 		}
 		FVStatus, ViolStatus, Violation0 = GetViolation(CBIndex, X0)
@@ -3870,25 +4255,11 @@ func GetMultiplier(X0, X1 []float64, CBIndex int, ConOrBnd int) (Status int, Mul
 		} // constraint satisfied at end point so current CV length OK
 	} else {
 		// It's a variable bound
-		Lower = lp.LP.Cols[CBIndex].BndLo
-		Upper = lp.LP.Cols[CBIndex].BndUp
-		Violation0 = 0.0
-		if X0[CBIndex] < Lower-featol {
-			Violation0 = Lower - X0[CBIndex]
-		}
-		if X0[CBIndex] > Upper+featol {
-			Violation0 = Upper - X0[CBIndex]
-		}
+		Violation0 = BoundViolation(CBIndex, X0[CBIndex])
 		if Violation0 == 0.0 {
 			return 1, 0.0
 		} // bounds satisfied so bail out
-		Violation1 = 0.0
-		if X1[CBIndex] < Lower-featol {
-			Violation1 = Lower - X1[CBIndex]
-		}
-		if X1[CBIndex] > Upper+featol {
-			Violation1 = Upper - X1[CBIndex]
-		}
+		Violation1 = BoundViolation(CBIndex, X1[CBIndex])
 		if Violation1 == 0.0 {
 			return 0, 1.0
 		} // satisfied at update point
@@ -4201,6 +4572,11 @@ func GetCV(Pt []float64, Mode int) (Status int, CV []float64, SFD float64, SINF
 	SumViol := make([]float64, len(Pt))         // Sum of violations (in terms of feasibility vector components)
 	SumWeightedViol := make([]float64, len(Pt)) // Sum of the weighted violations (weighted by violation)
 	SumWeights := make([]float64, len(Pt))      // Sum of the weights
+	var SumViolC, SumWeightedViolC []float64    // Compensation for SumViol and SumWeightedViol in the AccurateSums mode
+	if lp.AccurateSums {
+		SumViolC = make([]float64, len(Pt))
+		SumWeightedViolC = make([]float64, len(Pt))
+	}
 	//	CCPoint := make([]float64, len(Pt))         // Constraint consensus point
 	//	CV := make([]float64, len(Pt))              // Consensus Vector
 	//	BestPt := make([]float64, len(Pt))          // The best point seen in this CC run
@@ -4214,9 +4590,8 @@ func GetCV(Pt []float64, Mode int) (Status int, CV []float64, SFD float64, SINF
 	//	var Status int
 	var FVStatus int = 0
 	var ViolStatus int = 0
-	var ElNum int  // Element number
 	var ColNum int // Column number
-	var rhold, rhold1 float64
+	var rhold float64
 	var CVLength float64
 	//	var CVLengthLast float64
 	//	var MaxMultiplier float64
@@ -4229,6 +4604,7 @@ func GetCV(Pt []float64, Mode int) (Status int, CV []float64, SFD float64, SINF
 	//Status=0
 
 	// Zero the accumulators
+	CV = make([]float64, len(Pt))
 	NINF = 0
 	SFD = 0.0
 	SINF = 0.0
@@ -4258,29 +4634,16 @@ func GetCV(Pt []float64, Mode int) (Status int, CV []float64, SFD float64, SINF
 		//fmt.Println("After GetViolation call for con",icon,"Violation:",Violation,"FVStatus:",FVStatus,"ViolStatus:",ViolStatus)
 
 		if FVStatus > 0 {
-			fmt.Println("Error evaluating feasibility status for constraint", icon, ". Skipping it in GetCV.")
-			continue
+			// Numerical problem, so no consensus vector can be calculated
+			return 2, CV, SFD, SINF, NINF
 		}
 		if ViolStatus > 0 {
 			// not violated, so skip
 			continue
 		}
-		// Check length of feasibility vector
-		rhold = 0.0 // Accumulates length of feasibility vector
-		for iel := 0; iel < lp.LP.Rows[icon].NumEl; iel++ {
-			ElNum = lp.LP.Rows[icon].ElList[iel]
-			ColNum = lp.Element[ElNum].Col
-			rhold1 = Violation * lp.Element[ElNum].Value / lp.LP.Rows[icon].GradVecLenSq
-			rhold = rhold + rhold1*rhold1
-		}
-		if rhold < Alpha*Alpha {
-			// Feasibility vector is too short so skip this constraint
-			continue
-		}
-
 		// Constraint is violated
 		NINF++
-		FVLength = math.Sqrt(rhold)
+		FVLength = math.Abs(Violation) * lp.LP.Rows[icon].InvGradVecLen // Length of feasibility vector
 		SFD = SFD + FVLength
 		SINF = SINF + math.Abs(Violation)
 
@@ -4314,88 +4677,59 @@ func GetCV(Pt []float64, Mode int) (Status int, CV []float64, SFD float64, SINF
 		}
 
 		// Calculate the relevant elements of the feasibility vector
-		for iel := 0; iel < lp.LP.Rows[icon].NumEl; iel++ {
-			ElNum = lp.LP.Rows[icon].ElList[iel]
-			ColNum = lp.Element[ElNum].Col
+		for k := lp.LP.RowStart[icon]; k < lp.LP.RowStart[icon+1]; k++ {
+			ColNum = lp.LP.ColIdx[k]
 			NumViol[ColNum]++
-			SumViol[ColNum] = SumViol[ColNum] + Violation*lp.Element[ElNum].Value/lp.LP.Rows[icon].GradVecLenSq
-			SumWeightedViol[ColNum] = SumWeightedViol[ColNum] + Violation*lp.Element[ElNum].Value/lp.LP.Rows[icon].GradVecLenSq*math.Abs(Violation)
+			compensatedAdd(SumViol, SumViolC, ColNum, Violation*lp.LP.RowVal[k]*lp.LP.Rows[icon].InvGradVecLenSq)
+			compensatedAdd(SumWeightedViol, SumWeightedViolC, ColNum, Violation*lp.LP.RowVal[k]*lp.LP.Rows[icon].InvGradVecLenSq*math.Abs(Violation))
 			SumWeights[ColNum] = SumWeights[ColNum] + math.Abs(Violation)
 			if NewMaxViol {
-				FVMaxViol[ColNum] = Violation * lp.Element[ElNum].Value / lp.LP.Rows[icon].GradVecLenSq
+				FVMaxViol[ColNum] = Violation * lp.LP.RowVal[k] * lp.LP.Rows[icon].InvGradVecLenSq
 			}
 			if NewMaxFVLength {
-				FVMaxFVLength[ColNum] = Violation * lp.Element[ElNum].Value / lp.LP.Rows[icon].GradVecLenSq
+				FVMaxFVLength[ColNum] = Violation * lp.LP.RowVal[k] * lp.LP.Rows[icon].InvGradVecLenSq
 			}
 		}
 	} // end of loop on the constraints
 
 	// Run through the bounds looking for violations and making appropriate updates
 	for ivar := 0; ivar < lp.NumCols; ivar++ {
-		if Pt[ivar] >= lp.LP.Cols[ivar].BndLo-Alpha {
-			// greater than lower bound
-			if Pt[ivar] <= lp.LP.Cols[ivar].BndUp+Alpha {
-				// less than upper bound
-				continue
-			} else if Pt[ivar]-lp.LP.Cols[ivar].BndUp > Alpha {
-				// upper bound violated by large enough amount
-				NINF++
-				NumViol[ivar]++
-				rhold = lp.LP.Cols[ivar].BndUp - Pt[ivar]
-				SumViol[ivar] = SumViol[ivar] + rhold
-				SFD = SFD + math.Abs(rhold)
-				SumWeightedViol[ivar] = SumViol[ivar]
-				SumWeights[ivar] = SumWeights[ivar] + rhold
-
-				if Pt[ivar]-lp.LP.Cols[ivar].BndUp > MaxViol {
-					// There's a new maximum violation
-					MaxViol = Pt[ivar] - lp.LP.Cols[ivar].BndUp
-					// Empty the old FVMaxViol vector, fill it in the following step
-					for j := 0; j < lp.LP.NumCols; j++ {
-						FVMaxViol[j] = 0.0
-					}
-					FVMaxViol[ivar] = lp.LP.Cols[ivar].BndUp - Pt[ivar]
-				}
-				if math.Abs(rhold) > MaxFVLength {
-					// There's a new longest FV
-					MaxFVLength = math.Abs(rhold)
-					// Empty the old FVMaxFVLength and fill it in following step
-					for j := 0; j < lp.NumCols; j++ {
-						FVMaxFVLength[j] = 0.0
-					}
-					FVMaxFVLength[ivar] = lp.LP.Cols[ivar].BndUp - Pt[ivar]
-				}
-
-			}
-		} else if lp.LP.Cols[ivar].BndLo-Pt[ivar] > Alpha {
-			// lower bound violated by large enough amount
-			NINF++
-			NumViol[ivar]++
-			rhold = lp.LP.Cols[ivar].BndLo - Pt[ivar]
-			SumViol[ivar] = SumViol[ivar] + rhold
-			SFD = SFD + rhold
-			SumWeightedViol[ivar] = SumViol[ivar]
-			SumWeights[ivar] = SumWeights[ivar] + lp.LP.Cols[ivar].BndLo - Pt[ivar]
+		rhold = BoundViolation(ivar, Pt[ivar])
+		if rhold == 0.0 {
+			continue
+		}
+		NINF++
+		NumViol[ivar]++
+		compensatedAdd(SumViol, SumViolC, ivar, rhold)
+		SFD = SFD + math.Abs(rhold)
+		SumWeightedViol[ivar] = SumViol[ivar]
+		SumWeights[ivar] = SumWeights[ivar] + rhold
 
-			if lp.LP.Cols[ivar].BndLo-Pt[ivar] > MaxViol {
-				// There's a new maximum violation
-				MaxViol = lp.LP.Cols[ivar].BndLo - Pt[ivar]
-				// Empty the old FVMaxViol vector, fill it in the following step
-				for j := 0; j < lp.LP.NumCols; j++ {
-					FVMaxViol[j] = 0.0
-				}
-				FVMaxViol[ivar] = lp.LP.Cols[ivar].BndLo - Pt[ivar]
+		if math.Abs(rhold) > MaxViol {
+			// There's a new maximum violation
+			MaxViol = math.Abs(rhold)
+			// Empty the old FVMaxViol vector, fill it in the following step
+			for j := 0; j < lp.LP.NumCols; j++ {
+				FVMaxViol[j] = 0.0
 			}
-			if rhold > MaxFVLength {
-				// There's a new longest FV
-				MaxFVLength = rhold
-				//Empty the old FVMaxFVLength and fill it in the following step
-				for j := 0; j < lp.NumCols; j++ {
-					FVMaxFVLength[j] = 0.0
-				}
-				FVMaxFVLength[ivar] = rhold
+			FVMaxViol[ivar] = rhold
+		}
+		if math.Abs(rhold) > MaxFVLength {
+			// There's a new longest FV
+			MaxFVLength = math.Abs(rhold)
+			// Empty the old FVMaxFVLength and fill it in following step
+			for j := 0; j < lp.NumCols; j++ {
+				FVMaxFVLength[j] = 0.0
 			}
+			FVMaxFVLength[ivar] = rhold
+		}
+	}
 
+	if SumViolC != nil {
+		// Fold the compensation terms back in
+		for ivar := 0; ivar < lp.NumCols; ivar++ {
+			SumViol[ivar] = SumViol[ivar] + SumViolC[ivar]
+			SumWeightedViol[ivar] = SumWeightedViol[ivar] + SumWeightedViolC[ivar]
 		}
 	}
 
@@ -4789,7 +5123,7 @@ func SortByImpact () () {
 		ImpactData[i].Row = i
 		ImpactData[i].Sum = 0
 		//for every row
-		if lp.LP.Rows[i].Type == "N" {continue}
+		if lp.LP.Rows[i].Type == lp.RowN {continue}
 		for j:=0; j<lp.NumRows; j++ {
 			Impacted[j] = false
 		}
@@ -4801,7 +5135,7 @@ func SortByImpact () () {
 			for j:=0; j<lp.LP.Cols[ivar].NumEl; j++ {
 				jel:=lp.LP.Cols[ivar].ElList[j]
 				jrow:=lp.Element[jel].Row
-				if lp.LP.Rows[jrow].Type == "N" {continue}
+				if lp.LP.Rows[jrow].Type == lp.RowN {continue}
 				Impacted[jrow] = true
 			}
 		}
@@ -0,0 +1,65 @@
+package solver
+
+import (
+	"reflect"
+	"testing"
+)
+
+//=======================================================================================
+// Warm starts on the model of buildSimplexModel: x + y <= 4, x - y >= -2, x and y in [0, 3]
+func TestSolveWarm(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Warm    WARMSTART
+		AtOnce  bool      // returns the start point without a CC run
+		WantBox []float64 // BoxLo then BoxUp of LastWarmStart, nil: not checked
+	}{
+		{"feasible start", WARMSTART{Points: [][]float64{{1.0, 1.0}}}, true,
+			[]float64{0.9, 0.9, 1.1, 1.1}},
+		{"feasible start with a box cut down to the bounds", WARMSTART{Points: [][]float64{{1.0, 1.0}},
+			BoxLo: []float64{-5.0, 0.5}, BoxUp: []float64{2.0, 9.0}}, true,
+			[]float64{0.0, 0.5, 2.0, 3.0}},
+		{"second start point feasible", WARMSTART{Points: [][]float64{{3.0, 3.0}, {2.0, 1.0}}}, true, nil},
+		{"infeasible start", WARMSTART{Points: [][]float64{{3.0, 3.0}}}, false, nil},
+		{"start of the wrong length dropped", WARMSTART{Points: [][]float64{{1.0}}}, false, nil},
+	}
+	Save := RandomSeed
+	RandomSeed = 1
+	t.Cleanup(func() { RandomSeed = Save })
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			buildSimplexModel(t, 4.0)
+			Point, Status := SolveWarm(tt.Warm, 1.0e-6, 1.0e-4, 50, 4, 1.0e10, 1.0e-6)
+			if Status != 0 {
+				t.Fatalf("status %d, want 0 (feasible)", Status)
+			}
+			if _, NINF, _, _, _, _, _ := TestPoint(Point); NINF > 0 {
+				t.Errorf("point %v violates %d rows or bounds", Point, NINF)
+			}
+			if AtOnce := NumCCRuns == 0; AtOnce != tt.AtOnce {
+				t.Errorf("%d CC runs, returned at once %v, want %v", NumCCRuns, AtOnce, tt.AtOnce)
+			}
+			if tt.AtOnce && !reflect.DeepEqual(Point, feasibleStart(tt.Warm.Points)) {
+				t.Errorf("point %v, want the feasible start point", Point)
+			}
+			if tt.WantBox == nil {
+				return
+			}
+			Warm := LastWarmStart()
+			if !reflect.DeepEqual(append(Warm.BoxLo, Warm.BoxUp...), tt.WantBox) {
+				t.Errorf("box %v to %v, want %v", Warm.BoxLo, Warm.BoxUp, tt.WantBox)
+			}
+		})
+	}
+}
+
+//=======================================================================================
+// The first of the points that TestPoint finds feasible
+func feasibleStart(Points [][]float64) []float64 {
+	for _, Point := range Points {
+		if _, NINF, _, _, _, _, _ := TestPoint(Point); NINF == 0 {
+			return Point
+		}
+	}
+	return nil
+}
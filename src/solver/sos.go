@@ -0,0 +1,77 @@
+package solver
+
+// Special ordered sets. A set of type 1 allows at most one of its members to be nonzero, and a
+// set of type 2 at most two, which must be adjacent in weight order. A member counts as nonzero
+// if it is more than featol from 0. A violated set counts once in NINF, and its violation is
+// the total magnitude of the members outside the admissible ones: the member (SOS1) or adjacent
+// pair (SOS2) with the largest magnitude. The repair step used by CC zeros those members, which
+// is the nearest point at which the set is satisfied.
+
+import (
+	"lp"
+	"math"
+)
+
+//=======================================================================================
+// Violation of special ordered set iset at Point, 0 if the set is satisfied. The members at
+// positions First to Last of the set, in weight order, are the ones that may stay nonzero.
+func SOSViolation(iset int, Point []float64) (Violation float64, First int, Last int) {
+
+	Set := &lp.LP.SOS[iset]
+	if len(Set.Cols) == 0 {
+		return 0.0, 0, -1
+	}
+	Width := Set.Type // number of adjacent members that may be nonzero
+	if Width > len(Set.Cols) {
+		Width = len(Set.Cols)
+	}
+	Best := -1.0
+	for k := 0; k+Width <= len(Set.Cols); k++ {
+		Sum := 0.0
+		for j := k; j < k+Width; j++ {
+			Sum += math.Abs(Point[Set.Cols[j]])
+		}
+		if Sum > Best {
+			Best, First = Sum, k
+		}
+	}
+	Last = First + Width - 1
+	for k, ivar := range Set.Cols {
+		if (k < First || k > Last) && math.Abs(Point[ivar]) > featol {
+			Violation += math.Abs(Point[ivar])
+		}
+	}
+	return Violation, First, Last
+}
+
+//=======================================================================================
+// Zeros the members of the violated special ordered sets that are outside the admissible
+// ones. Returns the number of values changed.
+func RepairSOS(Point []float64) (NumZeroed int) {
+	for iset := range lp.LP.SOS {
+		Violation, First, Last := SOSViolation(iset, Point)
+		if Violation == 0.0 {
+			continue
+		}
+		for k, ivar := range lp.LP.SOS[iset].Cols {
+			if (k < First || k > Last) && Point[ivar] != 0.0 {
+				Point[ivar] = 0.0
+				NumZeroed++
+			}
+		}
+	}
+	return NumZeroed
+}
+
+//=======================================================================================
+// The largest member of violated set iset that is outside the admissible ones, or -1
+func sosWorstMember(iset int, Point []float64) (Worst int) {
+	_, First, Last := SOSViolation(iset, Point)
+	Worst = -1
+	for k, ivar := range lp.LP.SOS[iset].Cols {
+		if (k < First || k > Last) && (Worst < 0 || math.Abs(Point[ivar]) > math.Abs(Point[Worst])) {
+			Worst = ivar
+		}
+	}
+	return Worst
+}